@@ -2,91 +2,68 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"io"
+	"sync"
 
 	"github.com/apsdehal/go-logger"
+	"github.com/kkentzo/ork/pkg/ork"
 )
 
+// records the log messages (per level) and the output
 type MockLogger struct {
+	mu      sync.Mutex
 	logs    map[logger.LogLevel][]string
 	outputs []string
-	*OrkLogger
+	ork.Logger
 }
 
 func NewMockLogger() *MockLogger {
-	logs := map[logger.LogLevel][]string{}
-	levels := []logger.LogLevel{
-		logger.CriticalLevel,
-		logger.ErrorLevel,
-		logger.WarningLevel,
-		logger.NoticeLevel,
-		logger.InfoLevel,
-		logger.DebugLevel,
-	}
-	for _, lvl := range levels {
-		logs[lvl] = []string{}
-	}
-	actual, err := NewLogger()
+	actual, err := ork.NewLoggerTo(io.Discard, io.Discard)
 	if err != nil {
-		fmt.Println("failed to initialize MockLogger!")
-		os.Exit(1)
+		panic(err)
 	}
-	return &MockLogger{logs: logs, outputs: []string{}, OrkLogger: actual.(*OrkLogger)}
-}
-
-func (l *MockLogger) SetLogLevel(lvl string) error {
-	return l.OrkLogger.SetLogLevel(lvl)
-}
-
-func (l *MockLogger) GetLogLevel() logger.LogLevel {
-	return l.OrkLogger.level
+	return &MockLogger{logs: map[logger.LogLevel][]string{}, outputs: []string{}, Logger: actual}
 }
 
 func (l *MockLogger) Logs(lvl logger.LogLevel) []string {
-	return l.logs[lvl]
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string{}, l.logs[lvl]...)
 }
 
 func (l *MockLogger) Outputs() []string {
-	return l.outputs
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string{}, l.outputs...)
 }
 
-func (l *MockLogger) Fatal(msg string) {
-	l.logs[logger.CriticalLevel] = append(l.logs[logger.CriticalLevel], msg)
+func (l *MockLogger) log(lvl logger.LogLevel, msg string) {
+	if lvl <= l.GetLogLevel() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.logs[lvl] = append(l.logs[lvl], msg)
+	}
 }
 
-func (l *MockLogger) Fatalf(msg string, a ...interface{}) {
-	l.Fatal(fmt.Sprintf(msg, a...))
-}
+func (l *MockLogger) Fatal(msg string) { l.log(logger.CriticalLevel, msg) }
 
-func (l *MockLogger) Error(msg string) {
-	if logger.ErrorLevel <= l.GetLogLevel() {
-		l.logs[logger.ErrorLevel] = append(l.logs[logger.ErrorLevel], msg)
-	}
-}
+func (l *MockLogger) Fatalf(msg string, a ...interface{}) { l.Fatal(fmt.Sprintf(msg, a...)) }
 
-func (l *MockLogger) Errorf(msg string, a ...interface{}) {
-	l.Error(fmt.Sprintf(msg, a...))
-}
+func (l *MockLogger) Error(msg string) { l.log(logger.ErrorLevel, msg) }
 
-func (l *MockLogger) Info(msg string) {
-	if logger.InfoLevel <= l.GetLogLevel() {
-		l.logs[logger.InfoLevel] = append(l.logs[logger.InfoLevel], msg)
-	}
-}
+func (l *MockLogger) Errorf(msg string, a ...interface{}) { l.Error(fmt.Sprintf(msg, a...)) }
 
-func (l *MockLogger) Infof(msg string, a ...interface{}) {
-	l.Info(fmt.Sprintf(msg, a...))
-}
+func (l *MockLogger) Info(msg string) { l.log(logger.InfoLevel, msg) }
 
-func (l *MockLogger) Debug(msg string) {
-	if logger.DebugLevel <= l.GetLogLevel() {
-		l.logs[logger.DebugLevel] = append(l.logs[logger.DebugLevel], msg)
-	}
-}
+func (l *MockLogger) Infof(msg string, a ...interface{}) { l.Info(fmt.Sprintf(msg, a...)) }
 
-func (l *MockLogger) Debugf(msg string, a ...interface{}) {
-	l.Debug(fmt.Sprintf(msg, a...))
-}
+func (l *MockLogger) Debug(msg string) { l.log(logger.DebugLevel, msg) }
+
+func (l *MockLogger) Debugf(msg string, a ...interface{}) { l.Debug(fmt.Sprintf(msg, a...)) }
+
+func (l *MockLogger) Trace(msg string) { l.log(ork.TraceLevel, msg) }
+
+func (l *MockLogger) Tracef(msg string, a ...interface{}) { l.Trace(fmt.Sprintf(msg, a...)) }
 
 func (l *MockLogger) Write(p []byte) (n int, err error) {
 	l.Output(string(p))
@@ -94,5 +71,9 @@ func (l *MockLogger) Write(p []byte) (n int, err error) {
 }
 
 func (l *MockLogger) Output(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.outputs = append(l.outputs, msg)
 }
+
+func (l *MockLogger) OutputStderr(msg string) {}
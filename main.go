@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/signal"
+	"path/filepath"
 	"regexp"
-	"sort"
+	"strings"
+	"syscall"
 
+	"github.com/kkentzo/ork/pkg/ork"
 	"github.com/urfave/cli/v2"
 )
 
@@ -31,16 +34,7 @@ OPTIONS:
 `
 }
 
-// return all the labels in the orkfile in alphabetical order
-func AllLabels(f *Orkfile) []string {
-	labels := f.Labels(Actionable)
-	sort.Slice(labels, func(i, j int) bool {
-		return labels[i] < labels[j]
-	})
-	return labels
-}
-
-func runApp(ctx context.Context, args []string, logger Logger) error {
+func runApp(ctx context.Context, args []string, logger ork.Logger) error {
 	app := cli.App{
 		Name:        "ork",
 		Description: "workflow management for software projects",
@@ -49,12 +43,31 @@ func runApp(ctx context.Context, args []string, logger Logger) error {
 				Name:    "file",
 				Aliases: []string{"f", "p"},
 				Usage:   "path to Orkfile",
-				Value:   DEFAULT_ORKFILE,
+				Value:   ork.DEFAULT_ORKFILE,
 			},
 			&cli.StringFlag{
 				Name:  "log-level",
-				Usage: "log level (one of 'info', 'error', 'debug')",
-				Value: LOG_LEVEL_INFO,
+				Usage: "log level (one of 'info', 'error', 'debug', 'trace')",
+				Value: ork.LOG_LEVEL_INFO,
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Aliases: []string{"q"},
+				Usage:   "only print the output of the actions and any errors (same as --log-level error)",
+			},
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+				Usage:   "print the details of the execution, such as timings and hook decisions (same as --log-level debug)",
+			},
+			&cli.BoolFlag{
+				Name:  "vv",
+				Usage: "print the internals of the execution as well (same as --log-level trace)",
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Usage: "log format (one of 'text', 'json')",
+				Value: ork.LOG_FORMAT_TEXT,
 			},
 			&cli.StringFlag{
 				Name:    "search",
@@ -67,30 +80,90 @@ func runApp(ctx context.Context, args []string, logger Logger) error {
 				Usage:   "list all tasks",
 			},
 			&cli.BoolFlag{
-				Name:    "info",
+				Name:    "pick",
 				Aliases: []string{"i"},
-				Usage:   "print information about the provided task",
+				Usage:   "choose the tasks to run from an interactive list (same as: ork pick)",
 			},
 			&cli.BoolFlag{
-				Name:    "version",
-				Aliases: []string{"v"},
-				Usage:   "show program version",
+				Name:    "keep-going",
+				Aliases: []string{"k"},
+				Usage:   "continue with the remaining tasks after a failure and report all failures at the end",
+			},
+			&cli.DurationFlag{
+				Name:  "grace-period",
+				Usage: "time to wait for a running action to exit after an interrupt before killing it",
+				Value: ork.DEFAULT_GRACE_PERIOD,
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "presentation of the tasks' output (one of 'plain', 'prefixed', 'grouped', 'github')",
+				Value: ork.OUTPUT_PLAIN,
+			},
+			&cli.BoolFlag{
+				Name:  "summary",
+				Usage: "print a summary of the executed tasks with their timings at the end of the run",
+			},
+			&cli.StringFlag{
+				Name:  "log-dir",
+				Usage: "record the output of every task in a separate file (named after the task) in this directory",
+			},
+			&cli.StringFlag{
+				Name:  "trace",
+				Usage: "export an OpenTelemetry trace of the run to a JSON file or an OTLP/HTTP endpoint (e.g. http://localhost:4318)",
+			},
+			&cli.StringFlag{
+				Name:  "report-junit",
+				Usage: "write a junit XML report of the executed tasks to the supplied path",
+			},
+			&cli.BoolFlag{
+				Name:  "history",
+				Usage: "list the past runs (or show the details of the run with the supplied id)",
+			},
+			&cli.BoolFlag{
+				Name:  "last",
+				Usage: "re-run the tasks that failed in the most recent failed run",
+			},
+			&cli.BoolFlag{
+				Name:  "history-logs",
+				Usage: "record the output of the tasks along with the run in the history",
+			},
+			&cli.BoolFlag{
+				Name:  "no-history",
+				Usage: "do not record the run in the history",
+			},
+			&cli.StringSliceFlag{
+				Name:  "param",
+				Usage: "set a parameter of the run in the form KEY=VALUE (available to templates)",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "assume yes in confirmations (and accept the default values of prompts in non-interactive mode)",
+			},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Aliases: []string{"n"},
+				Usage:   "show the actions (and the files changed by builtins) without executing them",
+			},
+			&cli.BoolFlag{
+				Name:  "version",
+				Usage: "show program version",
 			},
 		},
 		EnableBashCompletion: true,
 		BashComplete: func(c *cli.Context) {
 			// read Orkfile contents
-			contents, err := Read(DEFAULT_ORKFILE)
+			contents, err := ork.Read(ork.DEFAULT_ORKFILE)
 			if err != nil {
 				return
 			}
 			// parse file
-			orkfile := New()
+			orkfile := ork.New()
 			if err := orkfile.Parse(contents); err != nil {
 				return
 			}
 			// return the available task to `complete` command
-			for _, lbl := range orkfile.Labels(Actionable) {
+			for _, lbl := range orkfile.Labels(ork.Actionable) {
 				fmt.Println(lbl)
 			}
 		},
@@ -100,19 +173,54 @@ func runApp(ctx context.Context, args []string, logger Logger) error {
 				os.Exit(0)
 			}
 
-			// set log level for logger
-			if err := logger.SetLogLevel(c.String("log-level")); err != nil {
+			// set log format and level for logger
+			if err := logger.SetFormat(c.String("log-format")); err != nil {
+				return err
+			}
+			if err := logger.SetLogLevel(logLevel(c)); err != nil {
+				return err
+			}
+
+			if err := ork.ValidateOutputMode(c.String("output")); err != nil {
 				return err
 			}
 
-			// read Orkfile contents
-			contents, err := Read(c.String("file"))
+			params, err := parseParams(c.StringSlice("param"))
 			if err != nil {
-				return fmt.Errorf("failed to find Orkfile in path %s", c.String("file"))
+				return err
 			}
-			orkfile := New()
-			if err := orkfile.Parse(contents); err != nil {
-				return fmt.Errorf("failed to parse Orkfile: %v", err)
+
+			// the history is stored alongside the Orkfile
+			history := ork.NewHistory(filepath.Join(filepath.Dir(c.String("file")), ork.DEFAULT_HISTORY_DIR)).
+				WithArgs(args[1:]).
+				WithLogs(c.Bool("history-logs"))
+			if c.Bool("no-history") {
+				history = nil
+			}
+
+			// do we just need to show the history?
+			if c.Bool("history") {
+				if history == nil {
+					return errors.New("--history can not be combined with --no-history")
+				}
+				return showHistory(history, c.Args().First(), logger)
+			}
+
+			// load the Orkfile
+			orkfile := ork.New().
+				WithKeepGoing(c.Bool("keep-going")).
+				WithGracePeriod(c.Duration("grace-period")).
+				WithOutputMode(c.String("output")).
+				WithSummary(c.Bool("summary")).
+				WithJUnitReport(c.String("report-junit")).
+				WithLogDir(c.String("log-dir")).
+				WithTrace(c.String("trace")).
+				WithParams(params).
+				WithYes(c.Bool("yes")).
+				WithDryRun(c.Bool("dry-run")).
+				WithHistory(history)
+			if err := orkfile.Load(c.String("file")); err != nil {
+				return err
 			}
 
 			// do we just need to search the labels?
@@ -121,7 +229,7 @@ func runApp(ctx context.Context, args []string, logger Logger) error {
 				if term == "" {
 					return errors.New("no search term provided to -s")
 				}
-				labels := AllLabels(orkfile)
+				labels := ork.AllLabels(orkfile)
 				for _, label := range labels {
 					if matched, err := regexp.MatchString(term, label); err != nil {
 						return fmt.Errorf("search term %s is an invalid regular expression", term)
@@ -134,9 +242,35 @@ func runApp(ctx context.Context, args []string, logger Logger) error {
 
 			// read in requested task labels
 			labels := c.Args().Slice()
+			if c.Bool("pick") && len(labels) > 0 {
+				return errors.New("--pick can not be combined with task labels")
+			}
+
+			// do we need to re-run the failed tasks of the last failed run?
+			if c.Bool("last") {
+				if history == nil {
+					return errors.New("--last can not be combined with --no-history")
+				}
+				run, err := history.LastFailed()
+				if err != nil {
+					return err
+				}
+				if labels = run.FailedTasks(); len(labels) == 0 {
+					labels = run.Labels
+				}
+				logger.Infof("re-running the failed tasks of run %s: %s", run.ID, strings.Join(labels, " "))
+			}
+
+			// do we need to let the user pick the tasks?
+			// (`ork pick` is only honored if the Orkfile does not contain a task named pick)
+			if c.Bool("pick") || (len(labels) == 1 && labels[0] == "pick" && orkfile.Info("pick") == "") {
+				if labels, err = pickTasks(orkfile, c.App.Reader, c.App.ErrWriter); err != nil {
+					return err
+				}
+			}
 
 			if c.Bool("list") {
-				labels := AllLabels(orkfile)
+				labels := ork.AllLabels(orkfile)
 				for _, label := range labels {
 					logger.Output(orkfile.Info(label) + "\n")
 				}
@@ -149,31 +283,79 @@ func runApp(ctx context.Context, args []string, logger Logger) error {
 	return app.Run(args)
 }
 
+// the log level according to the verbosity flags (if any) or the --log-level flag
+func logLevel(c *cli.Context) string {
+	switch {
+	case c.Bool("quiet"):
+		return ork.LOG_LEVEL_ERROR
+	case c.Bool("vv"):
+		return ork.LOG_LEVEL_TRACE
+	case c.Bool("verbose"):
+		return ork.LOG_LEVEL_DEBUG
+	default:
+		return c.String("log-level")
+	}
+}
+
+// let the user choose the tasks to run using the fuzzy finder
+func pickTasks(orkfile *ork.Orkfile, in io.Reader, out io.Writer) ([]string, error) {
+	if f, ok := in.(*os.File); ok && !ork.IsTerminal(f) {
+		return nil, errors.New("the task picker requires a terminal")
+	}
+	return ork.NewPicker(ork.PickerItems(orkfile)).WithInput(in).WithOutput(out).Run()
+}
+
+// parse the KEY=VALUE parameters
+func parseParams(values []string) (map[string]string, error) {
+	params := map[string]string{}
+	for _, value := range values {
+		kv := strings.SplitN(value, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid parameter %s (expected KEY=VALUE)", value)
+		}
+		params[kv[0]] = kv[1]
+	}
+	return params, nil
+}
+
+// print the past runs or the details of the run with the supplied id
+func showHistory(history *ork.History, id string, logger ork.Logger) error {
+	if id == "" {
+		runs, err := history.String()
+		if err != nil {
+			return err
+		}
+		logger.Output(runs)
+		return nil
+	}
+	run, err := history.Get(id)
+	if err != nil {
+		return err
+	}
+	logger.Output(run.String())
+	return nil
+}
+
 func main() {
 	prepareCli()
+	ork.Version = OrkVersion
 
-	logger, err := NewLogger()
+	logger, err := ork.NewLogger()
 	if err != nil {
 		fmt.Println("failed to initialize logger")
 		os.Exit(1)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, os.Interrupt)
-	go func(cancel context.CancelFunc) {
-		// here, we catch the SIGINT signal to prevent the parent process (ork)
-		// from being immediately interrupted (and, hence, killed)
-		// we rely on the fact that the interrupt signal (SIGINT) will be sent
-		// to the entire process group by the shell
-		// so it will be received by the current action (os.Process) as well
-		<-sigs
-		// if this happens, then we need to stop the workflow, so that
-		// no more actions are executed
-		cancel()
-	}(cancel)
-
-	if err := runApp(ctx, os.Args, logger); err != nil {
+	// here, we catch the termination signals to prevent ork from being
+	// immediately killed; the context will be cancelled, so that no more
+	// actions are executed, and the received signal will be forwarded to the
+	// process group of the running action (followed by a SIGKILL after the grace period)
+	ctx, cancel := ork.WithSignals(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	err = runApp(ctx, os.Args, logger)
+	cancel()
+	if err != nil {
 		logger.Error(err.Error())
+		os.Exit(ork.ExitCode(err))
 	}
 }
@@ -3,9 +3,12 @@ package main
 import (
 	"context"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/apsdehal/go-logger"
+	"github.com/kkentzo/ork/pkg/ork"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -57,7 +60,7 @@ func Test_Ork_Command(t *testing.T) {
 	}
 	for _, kase := range kases {
 		logger := NewMockLogger()
-		kase.args = append([]string{"exe", "-p", orkfile_path}, kase.args...)
+		kase.args = append([]string{"exe", "-p", orkfile_path, "--no-history"}, kase.args...)
 		require.NoError(t, runApp(context.Background(), kase.args, logger), kase.description)
 		out := logger.Outputs()
 		require.Equal(t, len(kase.output), len(out), kase.description)
@@ -90,7 +93,7 @@ func Test_Ork_Command_Errors(t *testing.T) {
 	}
 	for _, kase := range kases {
 		log := NewMockLogger()
-		kase.args = append([]string{"exe", "-f", orkfile_path}, kase.args...)
+		kase.args = append([]string{"exe", "-f", orkfile_path, "--no-history"}, kase.args...)
 		err := runApp(context.Background(), kase.args, log)
 		require.Error(t, err, kase.description)
 		assert.Equal(t, kase.errmsg, err.Error(), kase.description)
@@ -103,7 +106,7 @@ func Test_Ork_Command_MalformedOrkfile(t *testing.T) {
 	defer os.Remove(orkfile_path)
 
 	log := NewMockLogger()
-	args := []string{"exe", "-p", orkfile_path}
+	args := []string{"exe", "-p", orkfile_path, "--no-history"}
 	err := runApp(context.Background(), args, log)
 	assert.ErrorContains(t, err, "failed to parse Orkfile")
 }
@@ -116,17 +119,17 @@ func Test_Ork_Command_LogLevel(t *testing.T) {
 	log := NewMockLogger()
 
 	// let's try an invalid log level
-	args := []string{"exe", "-p", orkfile_path, "--log-level", "invalid"}
+	args := []string{"exe", "-p", orkfile_path, "--no-history", "--log-level", "invalid"}
 	err := runApp(context.Background(), args, log)
 	assert.ErrorContains(t, err, "unknown log level: invalid")
 
 	// let's try the default log level
-	args = []string{"exe", "-p", orkfile_path, "foo"}
+	args = []string{"exe", "-p", orkfile_path, "--no-history", "foo"}
 	assert.NoError(t, runApp(context.Background(), args, log))
 	assert.Empty(t, log.Logs(logger.DebugLevel))
 
 	// let's try the debug log level
-	args = []string{"exe", "-p", orkfile_path, "--log-level", "debug", "foo"}
+	args = []string{"exe", "-p", orkfile_path, "--no-history", "--log-level", "debug", "foo"}
 	assert.NoError(t, runApp(context.Background(), args, log))
 	assert.NotEmpty(t, log.Logs(logger.DebugLevel))
 }
@@ -150,7 +153,7 @@ func Test_Ork_Command_Search(t *testing.T) {
 
 	for _, kase := range kases {
 		logger := NewMockLogger()
-		args := []string{"exe", "-p", orkfile_path, "-s", kase.term}
+		args := []string{"exe", "-p", orkfile_path, "--no-history", "-s", kase.term}
 		require.NoError(t, runApp(context.Background(), args, logger), kase.description)
 
 		out := logger.Outputs()
@@ -178,9 +181,198 @@ func Test_Ork_Command_Search_Error(t *testing.T) {
 
 	for _, kase := range kases {
 		logger := NewMockLogger()
-		args := []string{"exe", "-p", orkfile_path, "-s", kase.term}
+		args := []string{"exe", "-p", orkfile_path, "--no-history", "-s", kase.term}
 		err := runApp(context.Background(), args, logger)
 
 		assert.ErrorContains(t, err, kase.errmsg)
 	}
 }
+
+func Test_Ork_Command_KeepGoing(t *testing.T) {
+	orkfile_path := "Orkfile.command_keep_going.yml"
+	os.WriteFile(orkfile_path, []byte(`
+tasks:
+  - name: a
+    actions:
+      - bash -c "exit 1"
+  - name: b
+    actions:
+      - echo b
+`), os.ModePerm)
+	defer os.Remove(orkfile_path)
+
+	log := NewMockLogger()
+	args := []string{"exe", "-p", orkfile_path, "--no-history", "a", "b"}
+	assert.Error(t, runApp(context.Background(), args, log))
+	assert.Empty(t, log.Outputs())
+
+	log = NewMockLogger()
+	args = []string{"exe", "-p", orkfile_path, "--no-history", "--keep-going", "a", "b"}
+	assert.ErrorContains(t, runApp(context.Background(), args, log), "[a] action failed: exit status 1")
+	assert.Equal(t, []string{"b\n"}, log.Outputs())
+}
+
+func Test_Ork_Command_Verbosity(t *testing.T) {
+	orkfile_path := "Orkfile.command_verbosity.yml"
+	os.WriteFile(orkfile_path, []byte(`
+tasks:
+  - name: foo
+    env:
+      - VAR: foo
+    actions:
+      - echo $VAR
+    on_success:
+      - echo done
+  - name: quiet
+    silent: true
+    actions:
+      - echo quiet
+`), os.ModePerm)
+	defer os.Remove(orkfile_path)
+
+	kases := []struct {
+		description string
+		flags       []string
+		info        int // the expected number of messages per level
+		debug       int
+		trace       int
+	}{
+		{"default", []string{}, 1, 0, 0},
+		{"quiet", []string{"-q"}, 0, 0, 0},
+		{"verbose", []string{"-v"}, 1, 6, 0},
+		{"very verbose", []string{"-vv"}, 1, 6, 2},
+		{"quiet takes precedence", []string{"-q", "-v"}, 0, 0, 0},
+	}
+	for _, kase := range kases {
+		log := NewMockLogger()
+		args := append(append([]string{"exe", "-p", orkfile_path, "--no-history"}, kase.flags...), "foo")
+		require.NoError(t, runApp(context.Background(), args, log), kase.description)
+		// the output of the actions is always printed
+		assert.Equal(t, []string{"foo\n", "done\n"}, log.Outputs(), kase.description)
+		assert.Equal(t, kase.info, len(log.Logs(logger.InfoLevel)), kase.description)
+		assert.Equal(t, kase.debug, len(log.Logs(logger.DebugLevel)), kase.description)
+		assert.Equal(t, kase.trace, len(log.Logs(ork.TraceLevel)), kase.description)
+	}
+
+	// silent tasks do not echo their actions at the info level
+	log := NewMockLogger()
+	require.NoError(t, runApp(context.Background(), []string{"exe", "-p", orkfile_path, "--no-history", "quiet"}, log))
+	assert.Equal(t, []string{"quiet\n"}, log.Outputs())
+	assert.Empty(t, log.Logs(logger.InfoLevel))
+	log = NewMockLogger()
+	require.NoError(t, runApp(context.Background(), []string{"exe", "-p", orkfile_path, "--no-history", "-v", "quiet"}, log))
+	assert.Contains(t, log.Logs(logger.DebugLevel), "[quiet] echo quiet")
+}
+
+func Test_Ork_Command_Params(t *testing.T) {
+	orkfile_path := "Orkfile.command_params.yml"
+	os.WriteFile(orkfile_path, []byte(`
+tasks:
+  - name: deploy
+    template: true
+    actions:
+      - echo {{ param "stage" | default "dev" }}
+`), os.ModePerm)
+	defer os.Remove(orkfile_path)
+
+	log := NewMockLogger()
+	args := []string{"exe", "-p", orkfile_path, "--no-history", "--param", "stage=production", "deploy"}
+	require.NoError(t, runApp(context.Background(), args, log))
+	assert.Equal(t, []string{"production\n"}, log.Outputs())
+
+	log = NewMockLogger()
+	args = []string{"exe", "-p", orkfile_path, "--no-history", "deploy"}
+	require.NoError(t, runApp(context.Background(), args, log))
+	assert.Equal(t, []string{"dev\n"}, log.Outputs())
+
+	args = []string{"exe", "-p", orkfile_path, "--no-history", "--param", "stage", "deploy"}
+	assert.ErrorContains(t, runApp(context.Background(), args, NewMockLogger()), "invalid parameter stage")
+}
+
+func Test_Ork_Command_Pick(t *testing.T) {
+	orkfile_path := "Orkfile.command_pick.yml"
+	os.WriteFile(orkfile_path, []byte(`
+tasks:
+  - name: build
+    actions:
+      - echo build
+`), os.ModePerm)
+	defer os.Remove(orkfile_path)
+
+	stdin, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer stdin.Close()
+	defer w.Close()
+	pick := func(args ...string) error {
+		// the picker reads from the app's stdin, which is not a terminal here
+		app := os.Stdin
+		os.Stdin = stdin
+		defer func() { os.Stdin = app }()
+		return runApp(context.Background(), append([]string{"exe", "-p", orkfile_path, "--no-history"}, args...), NewMockLogger())
+	}
+	assert.ErrorContains(t, pick("--pick"), "the task picker requires a terminal")
+	assert.ErrorContains(t, pick("-i"), "the task picker requires a terminal")
+	assert.ErrorContains(t, pick("pick"), "the task picker requires a terminal")
+	assert.ErrorContains(t, pick("--pick", "build"), "--pick can not be combined with task labels")
+	// neither is /dev/null
+	null, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer null.Close()
+	stdin = null
+	assert.ErrorContains(t, pick("--pick"), "the task picker requires a terminal")
+
+	// a task named pick takes precedence
+	os.WriteFile(orkfile_path, []byte(`
+tasks:
+  - name: pick
+    actions:
+      - echo pick
+`), os.ModePerm)
+	log := NewMockLogger()
+	require.NoError(t, runApp(context.Background(), []string{"exe", "-p", orkfile_path, "--no-history", "pick"}, log))
+	assert.Equal(t, []string{"pick\n"}, log.Outputs())
+}
+
+func Test_Ork_Command_History(t *testing.T) {
+	dir := t.TempDir()
+	orkfile_path := filepath.Join(dir, "Orkfile.yml")
+	flag := filepath.Join(dir, "flag")
+	require.NoError(t, os.WriteFile(orkfile_path, []byte(`
+tasks:
+  - name: a
+    actions:
+      - echo a
+  - name: b
+    actions:
+      - bash -c "test -f `+flag+`"
+`), os.ModePerm))
+
+	log := NewMockLogger()
+	require.NoError(t, runApp(context.Background(), []string{"exe", "-f", orkfile_path, "a"}, log))
+	require.Error(t, runApp(context.Background(), []string{"exe", "-f", orkfile_path, "-k", "a", "b"}, log))
+
+	// list the runs (most recent first)
+	log = NewMockLogger()
+	require.NoError(t, runApp(context.Background(), []string{"exe", "-f", orkfile_path, "--history"}, log))
+	lines := strings.Split(strings.TrimSpace(strings.Join(log.Outputs(), "")), "\n")
+	require.Equal(t, 3, len(lines))
+	assert.Regexp(t, `^ID\s+TIME\s+STATUS\s+DURATION\s+TASKS$`, lines[0])
+	assert.Regexp(t, `\s+failed\s+.*\s+a b$`, lines[1])
+	assert.Regexp(t, `\s+ok\s+.*\s+a$`, lines[2])
+
+	// show the details of a single run
+	id := strings.Fields(lines[1])[0]
+	log = NewMockLogger()
+	require.NoError(t, runApp(context.Background(), []string{"exe", "-f", orkfile_path, "--history", id}, log))
+	assert.Contains(t, strings.Join(log.Outputs(), ""), orkfile_path+" -k a b\n")
+
+	// re-run the failed task
+	require.NoError(t, os.WriteFile(flag, []byte{}, os.ModePerm))
+	log = NewMockLogger()
+	require.NoError(t, runApp(context.Background(), []string{"exe", "-f", orkfile_path, "--last"}, log))
+	runs, err := ork.NewHistory(filepath.Join(dir, ork.DEFAULT_HISTORY_DIR)).Runs()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(runs))
+	assert.Equal(t, []string{"b"}, runs[2].Labels)
+	assert.Equal(t, ork.STATUS_OK, runs[2].Status)
+}
@@ -0,0 +1,309 @@
+package ork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/google/shlex"
+)
+
+const DEFAULT_GRACE_PERIOD = 5 * time.Second
+
+type Action struct {
+	statement   string
+	chdir       string
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+	logger      Logger
+	expandEnv   bool
+	ctx         context.Context
+	gracePeriod time.Duration
+	runner      Runner
+	data        TemplateData // available to the ork:template builtin
+	dryRun      bool
+}
+
+func NewAction(statement string) *Action {
+	return &Action{
+		statement:   statement,
+		stdin:       os.Stdin,
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		expandEnv:   true,
+		ctx:         context.Background(),
+		gracePeriod: DEFAULT_GRACE_PERIOD,
+		runner:      LocalRunner{},
+		data:        newTemplateData("", nil),
+	}
+}
+
+// the action's process will be signalled when the context is cancelled
+func (a *Action) WithContext(ctx context.Context) *Action {
+	a.ctx = ctx
+	return a
+}
+
+// the time to wait for the action's process to exit after it has been signalled
+// before it is killed
+func (a *Action) WithGracePeriod(gracePeriod time.Duration) *Action {
+	a.gracePeriod = gracePeriod
+	return a
+}
+
+func (a *Action) WithStdin(stdin io.Reader) *Action {
+	if stdin != nil {
+		a.stdin = stdin
+	}
+	return a
+}
+
+func (a *Action) WithStdout(stdout io.Writer) *Action {
+	a.stdout = stdout
+	return a
+}
+
+func (a *Action) WithStderr(stderr io.Writer) *Action {
+	a.stderr = stderr
+	return a
+}
+
+// the runner that produces the action's command
+func (a *Action) WithRunner(runner Runner) *Action {
+	if runner != nil {
+		a.runner = runner
+	}
+	return a
+}
+
+func (a *Action) WithTemplateData(data TemplateData) *Action {
+	a.data = data
+	return a
+}
+
+// describe the action (on stdout) instead of executing it
+func (a *Action) WithDryRun(dryRun bool) *Action {
+	a.dryRun = dryRun
+	return a
+}
+
+func (a *Action) WithEnvExpansion(expandEnv bool) *Action {
+	a.expandEnv = expandEnv
+	return a
+}
+
+func (a *Action) WithWorkingDirectory(chdir string) *Action {
+	a.chdir = chdir
+	return a
+}
+
+func (a *Action) Execute() error {
+	if a.dryRun {
+		return a.describe()
+	}
+	// builtins are executed in-process
+	if isBuiltin(a.statement) {
+		if a.expandEnv {
+			a.statement = os.ExpandEnv(a.statement)
+		}
+		if err := a.localBuiltin(); err != nil {
+			return err
+		}
+		return a.executeBuiltin(a.statement)
+	}
+	p, err := a.Start()
+	if err != nil {
+		return err
+	}
+	return p.Wait()
+}
+
+// builtins describe the files that they would change
+// any other statement is presented as it would be executed
+func (a *Action) describe() error {
+	if a.expandEnv {
+		a.statement = os.ExpandEnv(a.statement)
+	}
+	if isBuiltin(a.statement) {
+		if err := a.localBuiltin(); err != nil {
+			return err
+		}
+		return a.describeBuiltin(a.statement)
+	}
+	fmt.Fprintf(a.stdout, "would run: %s\n", redact(a.statement))
+	return nil
+}
+
+// builtins operate on the local filesystem (instead of the runner's host or container)
+func (a *Action) localBuiltin() error {
+	if _, ok := a.runner.(LocalRunner); !ok {
+		return &ActionError{Action: a.statement, ExitCode: -1, Err: errors.New("builtins can only be executed by the local runner")}
+	}
+	return nil
+}
+
+// spawn the action's process without waiting for it to finish
+func (a *Action) Start() (*Process, error) {
+	// first, setup the environment
+	if a.expandEnv {
+		a.statement = os.ExpandEnv(a.statement)
+	}
+	if isBuiltin(a.statement) {
+		return nil, &ActionError{Action: a.statement, ExitCode: -1, Err: errors.New("builtins can not be executed in the background")}
+	}
+	// the command (along with its working directory) is produced by the runner
+	cmd, err := a.runner.Command(a.statement, a.chdir)
+	if err != nil {
+		return nil, err
+	}
+
+	// setup the process' IO streams
+	cmd.Stderr = a.stderr
+	cmd.Stdin = a.stdin
+	cmd.Stdout = a.stdout
+	// an action that reads from the terminal needs to stay in the terminal's
+	// foreground process group, otherwise it will be stopped by the terminal
+	group := !isTerminal(a.stdin)
+	setupProcess(cmd, group)
+
+	// spawn the command
+	if err := cmd.Start(); err != nil {
+		return nil, &ActionError{Action: a.statement, ExitCode: -1, Err: err}
+	}
+
+	p := &Process{
+		statement:   a.statement,
+		cmd:         cmd,
+		group:       group,
+		gracePeriod: a.gracePeriod,
+		stop:        make(chan os.Signal, 1),
+		exited:      make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.exited)
+	}()
+	go func() {
+		p.forwardSignals(a.ctx)
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// a running action
+type Process struct {
+	statement   string
+	cmd         *exec.Cmd
+	group       bool
+	gracePeriod time.Duration
+	stop        chan os.Signal
+	exited      chan struct{} // closed when the process has exited
+	done        chan struct{} // closed when the process (and its group) has been cleaned up
+	err         error
+}
+
+// wait for the process to finish
+func (p *Process) Wait() error {
+	<-p.done
+	if p.err != nil {
+		return &ActionError{Action: p.statement, ExitCode: exitCode(p.err), Err: p.err}
+	}
+	return nil
+}
+
+// signal the process to stop and wait for it to finish
+// the process will be killed if it does not exit within the grace period
+func (p *Process) Stop(sig os.Signal) error {
+	select {
+	case p.stop <- sig:
+	default:
+	}
+	return p.Wait()
+}
+
+// a channel that is closed when the process exits
+func (p *Process) Exited() <-chan struct{} {
+	return p.exited
+}
+
+// forward the signal received by ork to the process (when the context is cancelled)
+// and kill the process if it has not exited after the grace period
+// a process in ork's process group (i.e. reading from the terminal) has already
+// received an interrupt from the terminal along with ork, so only the signals
+// that were sent to ork alone (e.g. SIGTERM) are forwarded to it and its children
+// the process group (or the children) of a signalled process is killed after the
+// process has exited, so that no orphaned children are left behind (the group of a
+// process that exits on its own is left intact, e.g. for the daemons that it has started)
+func (p *Process) forwardSignals(ctx context.Context) {
+	// the children of a process in ork's group (that can not be reached through the group)
+	var children []int
+	select {
+	case <-p.exited:
+		return
+	case <-ctx.Done():
+		if !p.group {
+			children = descendants(p.cmd.Process.Pid)
+		}
+		if p.group || !interruptedByTerminal(ctx) {
+			p.signal(SignalFrom(ctx), children)
+		}
+	case sig := <-p.stop:
+		if !p.group {
+			children = descendants(p.cmd.Process.Pid)
+		}
+		p.signal(sig, children)
+	}
+	timer := time.NewTimer(p.gracePeriod)
+	defer timer.Stop()
+	select {
+	case <-p.exited:
+		if p.group {
+			killProcess(p.cmd, p.group)
+		}
+	case <-timer.C:
+		killProcess(p.cmd, p.group)
+		<-p.exited
+	}
+	signalPids(children, os.Kill)
+}
+
+// send the signal to the process (or its group) along with the supplied children
+func (p *Process) signal(sig os.Signal, children []int) {
+	signalProcess(p.cmd, sig, p.group)
+	signalPids(children, sig)
+}
+
+// return the exit status of a finished process
+// processes that were terminated by a signal follow the shell convention (128+signal)
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return EXIT_CODE_FAILURE
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+func createCommand(statement string) (*exec.Cmd, error) {
+	var name string
+	var args []string
+
+	fields, err := shlex.Split(statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse action: %s\nerror: %v", statement, err)
+	}
+	if len(fields) > 0 {
+		name = fields[0]
+		args = fields[1:]
+	}
+	return exec.Command(name, args...), nil
+}
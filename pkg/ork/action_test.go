@@ -1,10 +1,11 @@
-package main
+package ork
 
 import (
 	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Action_Execute_Errors(t *testing.T) {
@@ -40,3 +41,29 @@ func Test_Action_Can_Accept_StandardInput(t *testing.T) {
 	assert.NoError(t, action.Execute())
 	assert.Contains(t, logger.Outputs(), "hello\n")
 }
+
+func Test_Action_Execute_Returns_ExitCode(t *testing.T) {
+	err := NewAction("bash -c \"exit 3\"").WithStdout(NewMockLogger()).Execute()
+	var aerr *ActionError
+	require.ErrorAs(t, err, &aerr)
+	assert.Equal(t, 3, aerr.ExitCode)
+
+	err = NewAction("bash -c \"kill -TERM $$\"").WithEnvExpansion(false).Execute()
+	require.ErrorAs(t, err, &aerr)
+	assert.Equal(t, 128+15, aerr.ExitCode)
+
+	err = NewAction("a_non_existent_program").Execute()
+	require.ErrorAs(t, err, &aerr)
+	assert.Equal(t, -1, aerr.ExitCode)
+	assert.ErrorContains(t, err, "failed to start action")
+}
+
+func Test_Action_Routes_Stderr(t *testing.T) {
+	logger := NewMockLogger()
+	action := NewAction("bash -c \"echo out; echo err >&2\"").
+		WithStdout(logger).
+		WithStderr(StderrWriter{logger})
+	assert.NoError(t, action.Execute())
+	assert.Equal(t, []string{"out\n"}, logger.Outputs())
+	assert.Equal(t, []string{"err\n"}, logger.Stderr())
+}
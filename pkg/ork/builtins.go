@@ -0,0 +1,348 @@
+package ork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/shlex"
+)
+
+// the prefix of the actions that are executed by ork itself (e.g. `ork:rm build`)
+const BUILTIN_PREFIX = "ork:"
+
+// a file operation that is executed in-process
+// relative paths are resolved against the action's working directory
+type builtin struct {
+	usage    string
+	args     func(n int) bool // validates the number of arguments
+	run      func(b *builtinCall) error
+	describe func(b *builtinCall) ([]string, error) // the operations of run (in dry-run mode)
+}
+
+// the invocation of a builtin by an action
+type builtinCall struct {
+	ctx  context.Context
+	dir  string
+	args []string
+	data TemplateData
+}
+
+var builtins = map[string]builtin{
+	"copy":     {"ork:copy SRC... DST", atLeast(2), builtinCopy, describeCopy},
+	"mkdir":    {"ork:mkdir DIR...", atLeast(1), builtinMkdir, describeEach("create directory")},
+	"rm":       {"ork:rm PATH...", atLeast(1), builtinRm, describeEach("remove")},
+	"move":     {"ork:move SRC DST", exactly(2), builtinMove, describeMove},
+	"touch":    {"ork:touch FILE...", atLeast(1), builtinTouch, describeEach("touch")},
+	"download": {"ork:download URL DST", exactly(2), builtinDownload, describeDownload},
+	"template": {"ork:template SRC DST", exactly(2), builtinTemplate, describeTemplate},
+}
+
+// the names of all the builtins in alphabetical order
+func Builtins() []string {
+	names := []string{}
+	for name := range builtins {
+		names = append(names, BUILTIN_PREFIX+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isBuiltin(statement string) bool {
+	return strings.HasPrefix(strings.TrimSpace(statement), BUILTIN_PREFIX)
+}
+
+func atLeast(min int) func(int) bool { return func(n int) bool { return n >= min } }
+
+func exactly(count int) func(int) bool { return func(n int) bool { return n == count } }
+
+// execute the (expanded) builtin statement
+func (a *Action) executeBuiltin(statement string) error {
+	b, call, err := a.parseBuiltin(statement)
+	if err != nil {
+		return err
+	}
+	if err := b.run(call); err != nil {
+		return &ActionError{Action: statement, ExitCode: EXIT_CODE_FAILURE, Err: err}
+	}
+	return nil
+}
+
+// write the operations of the (expanded) builtin statement to the action's stdout
+// without executing them
+func (a *Action) describeBuiltin(statement string) error {
+	b, call, err := a.parseBuiltin(statement)
+	if err != nil {
+		return err
+	}
+	operations, err := b.describe(call)
+	if err != nil {
+		return &ActionError{Action: statement, ExitCode: EXIT_CODE_FAILURE, Err: err}
+	}
+	for _, op := range operations {
+		fmt.Fprintf(a.stdout, "would %s\n", op)
+	}
+	return nil
+}
+
+func (a *Action) parseBuiltin(statement string) (builtin, *builtinCall, error) {
+	fields, err := shlex.Split(statement)
+	if err != nil {
+		return builtin{}, nil, &ActionError{Action: statement, ExitCode: -1, Err: fmt.Errorf("failed to parse action: %v", err)}
+	}
+	name := strings.TrimPrefix(fields[0], BUILTIN_PREFIX)
+	b, ok := builtins[name]
+	if !ok {
+		return builtin{}, nil, &ActionError{Action: statement, ExitCode: -1, Err: fmt.Errorf("unknown builtin: %s (one of %s)", fields[0], strings.Join(Builtins(), ", "))}
+	}
+	args := fields[1:]
+	if !b.args(len(args)) {
+		return builtin{}, nil, &ActionError{Action: statement, ExitCode: -1, Err: fmt.Errorf("usage: %s", b.usage)}
+	}
+	return b, &builtinCall{ctx: a.ctx, dir: a.chdir, args: args, data: a.data}, nil
+}
+
+// resolve the path against the action's working directory
+func (b *builtinCall) path(p string) string {
+	if filepath.IsAbs(p) || b.dir == "" {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(b.dir, p)
+}
+
+// copy files or directories (recursively)
+// multiple sources or an existing destination directory will be copied into it
+func builtinCopy(b *builtinCall) error {
+	targets, err := copyTargets(b)
+	if err != nil {
+		return err
+	}
+	for _, t := range targets {
+		if err := copyPath(t[0], t[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func describeCopy(b *builtinCall) ([]string, error) {
+	targets, err := copyTargets(b)
+	if err != nil {
+		return nil, err
+	}
+	operations := []string{}
+	for _, t := range targets {
+		operations = append(operations, fmt.Sprintf("copy %s to %s", t[0], t[1]))
+	}
+	return operations, nil
+}
+
+// the (source, target) pairs of the copy
+func copyTargets(b *builtinCall) ([][2]string, error) {
+	sources, dst := b.args[:len(b.args)-1], b.path(b.args[len(b.args)-1])
+	info, err := os.Stat(dst)
+	into := err == nil && info.IsDir()
+	if len(sources) > 1 && !into {
+		return nil, fmt.Errorf("destination %s is not a directory", dst)
+	}
+	targets := [][2]string{}
+	for _, src := range sources {
+		src = b.path(src)
+		target := dst
+		if into {
+			target = filepath.Join(dst, filepath.Base(src))
+		}
+		targets = append(targets, [2]string{src, target})
+	}
+	return targets, nil
+}
+
+func copyPath(src string, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyFile(src, dst, info.Mode())
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src string, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// the operation applied to every path argument, e.g. "remove build"
+func describeEach(operation string) func(b *builtinCall) ([]string, error) {
+	return func(b *builtinCall) ([]string, error) {
+		operations := []string{}
+		for _, path := range b.args {
+			operations = append(operations, fmt.Sprintf("%s %s", operation, b.path(path)))
+		}
+		return operations, nil
+	}
+}
+
+// create the directories (along with any missing parents)
+func builtinMkdir(b *builtinCall) error {
+	for _, dir := range b.args {
+		if err := os.MkdirAll(b.path(dir), 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove the files or directories (recursively); missing paths are ignored
+func builtinRm(b *builtinCall) error {
+	for _, path := range b.args {
+		if err := os.RemoveAll(b.path(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rename the file or directory (or copy it if it can not be renamed, e.g. across devices)
+func builtinMove(b *builtinCall) error {
+	src, dst := moveTarget(b)
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyPath(src, dst); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func describeMove(b *builtinCall) ([]string, error) {
+	src, dst := moveTarget(b)
+	return []string{fmt.Sprintf("move %s to %s", src, dst)}, nil
+}
+
+// an existing destination directory will contain the source
+func moveTarget(b *builtinCall) (string, string) {
+	src, dst := b.path(b.args[0]), b.path(b.args[1])
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+	return src, dst
+}
+
+// create the files (if they do not exist) and update their modification time
+func builtinTouch(b *builtinCall) error {
+	now := time.Now()
+	for _, path := range b.args {
+		path = b.path(path)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		f.Close()
+		if err := os.Chtimes(path, now, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetch the http(s) or file URL into the destination file
+func builtinDownload(b *builtinCall) error {
+	u, err := url.Parse(b.args[0])
+	if err != nil {
+		return err
+	}
+	var body io.ReadCloser
+	switch u.Scheme {
+	case "file":
+		// both file:///absolute/path and file:relative/path are supported
+		path := u.Opaque
+		if path == "" {
+			path = u.Host + u.Path
+		}
+		if body, err = os.Open(b.path(path)); err != nil {
+			return err
+		}
+	case "http", "https":
+		req, err := http.NewRequestWithContext(b.ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("failed to download %s: %s", u, resp.Status)
+		}
+		body = resp.Body
+	default:
+		return errors.New("only http(s) and file URLs can be downloaded")
+	}
+	defer body.Close()
+
+	out, err := os.Create(b.path(b.args[1]))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, body); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func describeDownload(b *builtinCall) ([]string, error) {
+	return []string{fmt.Sprintf("download %s to %s", b.args[0], b.path(b.args[1]))}, nil
+}
+
+// render the go template into the destination file
+func builtinTemplate(b *builtinCall) error {
+	_, err := b.data.RenderFile(b.path(b.args[0]), b.path(b.args[1]))
+	return err
+}
+
+// an up-to-date destination would not be modified
+func describeTemplate(b *builtinCall) ([]string, error) {
+	src, dst := b.path(b.args[0]), b.path(b.args[1])
+	changed, err := b.data.wouldRenderFile(src, dst)
+	if err != nil || !changed {
+		return []string{}, err
+	}
+	return []string{fmt.Sprintf("render %s into %s", src, dst)}, nil
+}
@@ -0,0 +1,152 @@
+package ork
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Builtins_Operate_On_Files(t *testing.T) {
+	dir := t.TempDir()
+	run := func(statement string) error {
+		return NewAction(statement).WithWorkingDirectory(dir).Execute()
+	}
+	read := func(path string) string {
+		contents, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(contents)
+	}
+
+	require.NoError(t, run("ork:mkdir src/nested out"))
+	require.NoError(t, run("ork:touch src/a.txt"))
+	assert.FileExists(t, filepath.Join(dir, "src", "a.txt"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "nested", "b.txt"), []byte("b"), 0600))
+
+	// copy a directory tree and a file into an existing directory
+	require.NoError(t, run("ork:copy src out"))
+	require.NoError(t, run("ork:copy src/nested/b.txt out/c.txt"))
+	assert.Equal(t, "b", read("out/src/nested/b.txt"))
+	assert.Equal(t, "b", read("out/c.txt"))
+	info, err := os.Stat(filepath.Join(dir, "out", "src", "nested", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, run("ork:move out/c.txt out/src"))
+	assert.NoFileExists(t, filepath.Join(dir, "out", "c.txt"))
+	assert.Equal(t, "b", read("out/src/c.txt"))
+
+	require.NoError(t, run("ork:rm out src missing"))
+	assert.NoDirExists(t, filepath.Join(dir, "out"))
+	assert.NoDirExists(t, filepath.Join(dir, "src"))
+}
+
+func Test_Builtin_Download(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/release.tgz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("release"))
+	}))
+	defer server.Close()
+
+	require.NoError(t, NewAction("ork:download "+server.URL+"/release.tgz "+filepath.Join(dir, "a")).Execute())
+	require.NoError(t, NewAction("ork:download file:a b").WithWorkingDirectory(dir).Execute())
+	contents, err := os.ReadFile(filepath.Join(dir, "b"))
+	require.NoError(t, err)
+	assert.Equal(t, "release", string(contents))
+
+	err = NewAction("ork:download " + server.URL + "/missing " + filepath.Join(dir, "c")).Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404 Not Found")
+	assert.Equal(t, EXIT_CODE_FAILURE, ExitCode(err))
+}
+
+func Test_Builtin_Template(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("UPSTREAM", "app:8080")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nginx.conf.tmpl"), []byte(`proxy_pass http://{{ env "UPSTREAM" }};`), 0644))
+
+	require.NoError(t, NewAction("ork:template nginx.conf.tmpl nginx.conf").WithWorkingDirectory(dir).Execute())
+	contents, err := os.ReadFile(filepath.Join(dir, "nginx.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "proxy_pass http://app:8080;", string(contents))
+}
+
+func Test_Builtin_Errors(t *testing.T) {
+	err := NewAction("ork:delete foo").Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown builtin: ork:delete")
+
+	err = NewAction("ork:move foo").Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "usage: ork:move SRC DST")
+
+	_, err = NewAction("ork:touch foo").Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can not be executed in the background")
+}
+
+func Test_Builtins_Dry_Run(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "out"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.tmpl"), []byte("{{ .OS }}"), 0644))
+	describe := func(statement string) string {
+		out := bytes.NewBuffer([]byte{})
+		require.NoError(t, NewAction(statement).WithWorkingDirectory(dir).WithStdout(out).WithDryRun(true).Execute())
+		return out.String()
+	}
+	path := func(p string) string { return filepath.Join(dir, p) }
+
+	assert.Equal(t, "would copy "+path("a.tmpl")+" to "+path("out/a.tmpl")+"\n", describe("ork:copy a.tmpl out"))
+	assert.Equal(t, "would move "+path("a.tmpl")+" to "+path("b.tmpl")+"\n", describe("ork:move a.tmpl b.tmpl"))
+	assert.Equal(t, "would remove "+path("out")+"\nwould remove "+path("a.tmpl")+"\n", describe("ork:rm out a.tmpl"))
+	assert.Equal(t, "would create directory "+path("c")+"\n", describe("ork:mkdir c"))
+	assert.Equal(t, "would render "+path("a.tmpl")+" into "+path("a")+"\n", describe("ork:template a.tmpl a"))
+	assert.Equal(t, "would run: rm -rf out\n", describe("rm -rf out"))
+
+	// nothing has been changed
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(entries))
+	assert.DirExists(t, path("out"))
+
+	// an up-to-date destination would not be rendered
+	require.NoError(t, NewAction("ork:template a.tmpl a").WithWorkingDirectory(dir).Execute())
+	assert.Equal(t, "", describe("ork:template a.tmpl a"))
+}
+
+func Test_Builtins_Require_The_Local_Runner(t *testing.T) {
+	registerTestRunner(t, "remote", func(*Task) (Runner, error) { return &SSHRunner{ssh: SSH{Host: "example.com"}}, nil })
+	kases := []struct {
+		description string
+		task        string
+		errmsg      string
+	}{
+		{"ssh", "ssh: {host: app.example.com}\n    actions: ['ork:rm /srv/app']", "[deploy] builtins can not be executed by the ssh runner: ork:rm /srv/app"},
+		{"container", "container: {image: alpine}\n    on_success: ['ork:touch done']", "[deploy] builtins can not be executed by the container runner: ork:touch done"},
+		{"custom", "runner: remote\n    on_failure: ['ork:mkdir logs']", "[deploy] builtins can not be executed by the remote runner: ork:mkdir logs"},
+	}
+	for _, kase := range kases {
+		yml := "tasks:\n  - name: deploy\n    " + kase.task + "\n"
+		assert.EqualError(t, New().Parse([]byte(yml)), kase.errmsg, kase.description)
+	}
+	// the local runner can be set explicitly
+	require.NoError(t, New().Parse([]byte("tasks:\n  - name: clean\n    runner: local\n    actions: ['ork:rm dist']\n")))
+
+	// the actions are refused as well (e.g. when they are executed directly)
+	dir := t.TempDir()
+	err := NewAction("ork:touch created").
+		WithWorkingDirectory(dir).
+		WithRunner(&SSHRunner{ssh: SSH{Host: "example.com"}}).
+		Execute()
+	assert.ErrorContains(t, err, "builtins can only be executed by the local runner")
+	assert.NoFileExists(t, filepath.Join(dir, "created"))
+}
@@ -0,0 +1,117 @@
+package ork
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/shlex"
+)
+
+const (
+	RUNNER_CONTAINER = "container" // the actions are executed in a container
+	RUNNER_DOCKER    = "docker"    // an alias of the container runner
+
+	DEFAULT_CONTAINER_ENGINE  = "docker"
+	DEFAULT_CONTAINER_WORKDIR = "/workspace" // where the project is mounted in the container
+)
+
+// the container in which the actions of a task are executed
+type Container struct {
+	Image   string   `yaml:"image"`
+	Engine  string   `yaml:"engine"`  // docker (default), podman or any compatible executable
+	Volumes []string `yaml:"volumes"` // additional mounts in the form host:container[:options]
+	Workdir string   `yaml:"workdir"` // the path of the project in the container
+	Env     []string `yaml:"env"`     // additional variables that will be passed through to the container
+	User    string   `yaml:"user"`
+}
+
+func init() {
+	RegisterRunner(RUNNER_CONTAINER, newContainerRunner)
+	RegisterRunner(RUNNER_DOCKER, newContainerRunner)
+}
+
+// executes every action in a new container (using `<engine> run`)
+// the project (i.e. the directory of the Orkfile) is mounted in the container's working
+// directory and the environment of the run is forwarded to the container
+type ContainerRunner struct {
+	container Container
+	project   string
+	env       []string // the keys of the task's own environment and the pass-through variables
+}
+
+func newContainerRunner(task *Task) (Runner, error) {
+	if task.Container == nil || task.Container.Image == "" {
+		return nil, &OrkfileError{errors.New("container image has not been set")}
+	}
+	project := task.project
+	if project == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		project = wd
+	}
+	env := []string{}
+	for _, e := range task.Env {
+		env = append(env, e.Keys()...)
+	}
+	env = append(env, task.Container.Env...)
+	return &ContainerRunner{container: *task.Container, project: project, env: env}, nil
+}
+
+func (r *ContainerRunner) Command(statement string, dir string) (*exec.Cmd, error) {
+	fields, err := shlex.Split(statement)
+	if err != nil {
+		return nil, &ActionError{Action: statement, ExitCode: -1, Err: err}
+	}
+	engine := r.container.Engine
+	if engine == "" {
+		engine = DEFAULT_CONTAINER_ENGINE
+	}
+	args := append([]string{"run"}, r.Args(dir)...)
+	return exec.Command(engine, append(args, fields...)...), nil
+}
+
+// the arguments of `<engine> run` up to (and including) the image
+func (r *ContainerRunner) Args(dir string) []string {
+	workdir := r.container.Workdir
+	if workdir == "" {
+		workdir = DEFAULT_CONTAINER_WORKDIR
+	}
+	args := []string{"--rm", "-i", "-v", r.project + ":" + workdir}
+	for _, volume := range r.container.Volumes {
+		args = append(args, "-v", r.volume(volume))
+	}
+	// the task's working directory is relative to the project
+	switch {
+	case dir == "":
+		args = append(args, "-w", workdir)
+	case filepath.IsAbs(dir):
+		args = append(args, "-w", dir)
+	default:
+		args = append(args, "-w", path.Join(workdir, filepath.ToSlash(dir)))
+	}
+	// only the names of the variables are passed to the engine, which reads their values
+	// from its own environment (i.e. the variables that have been set by ork during the run)
+	for _, key := range changedEnvKeys(r.env) {
+		args = append(args, "-e", key)
+	}
+	if r.container.User != "" {
+		args = append(args, "--user", r.container.User)
+	}
+	return append(args, r.container.Image)
+}
+
+// relative host paths are resolved against the project
+func (r *ContainerRunner) volume(volume string) string {
+	if !strings.HasPrefix(volume, ".") {
+		return volume
+	}
+	parts := strings.SplitN(volume, ":", 2)
+	parts[0] = filepath.Join(r.project, parts[0])
+	return strings.Join(parts, ":")
+}
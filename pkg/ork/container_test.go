@@ -0,0 +1,126 @@
+package ork
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// install a fake container engine in PATH that prints its arguments
+// along with the value of STAGE and exits with the value of EXIT_CODE
+func stubContainerEngine(t *testing.T, name string) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\"\necho \"STAGE=$STAGE\"\nexit ${EXIT_CODE:-0}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func Test_Task_Runs_Actions_In_Container(t *testing.T) {
+	stubContainerEngine(t, "podman")
+	resetInitialEnv(t)
+	project, err := os.Getwd()
+	require.NoError(t, err)
+
+	yml := `
+tasks:
+  - name: build
+    working_dir: cmd
+    env:
+      - STAGE: ci
+    container:
+      image: golang:1.17
+      engine: podman
+      volumes:
+        - ./cache:/go/pkg
+      env:
+        - GOFLAGS
+      user: "1000"
+    actions:
+      - go build -o "bin/my app"
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	logger := NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "build", logger))
+
+	expected := strings.Join([]string{
+		"run --rm -i",
+		"-v " + project + ":/workspace",
+		"-v " + filepath.Join(project, "cache") + ":/go/pkg",
+		"-w /workspace/cmd -e GOFLAGS -e STAGE --user 1000 golang:1.17",
+		"go build -o bin/my app",
+	}, " ")
+	assert.Equal(t, expected+"\nSTAGE=ci\n", strings.Join(logger.Outputs(), ""))
+}
+
+func Test_Container_Mounts_The_Orkfile_Directory(t *testing.T) {
+	stubContainerEngine(t, DEFAULT_CONTAINER_ENGINE)
+	resetInitialEnv(t)
+	project := t.TempDir()
+	path := filepath.Join(project, DEFAULT_ORKFILE)
+	require.NoError(t, os.WriteFile(path, []byte(`
+tasks:
+  - name: ci
+    env:
+      - STAGE: integration
+    tasks:
+      - name: test
+        runner: docker
+        container:
+          image: alpine
+        actions:
+          - go test
+`), 0644))
+	defer os.Unsetenv("STAGE")
+
+	// ork is executed from a directory other than the Orkfile's
+	f := New()
+	require.NoError(t, f.Load(path))
+	logger := NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "ci.test", logger))
+	// the environment of the parent task is forwarded as well
+	expected := "run --rm -i -v " + project + ":/workspace -w /workspace -e STAGE alpine go test"
+	assert.Equal(t, expected+"\nSTAGE=integration\n", strings.Join(logger.Outputs(), ""))
+}
+
+func Test_Task_In_Container_Propagates_Exit_Code(t *testing.T) {
+	stubContainerEngine(t, DEFAULT_CONTAINER_ENGINE)
+	t.Setenv("EXIT_CODE", "3")
+
+	yml := `
+tasks:
+  - name: build
+    container:
+      image: alpine
+    actions:
+      - false
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	err := f.RunTask(context.Background(), "build", NewMockLogger())
+	var actionErr *ActionError
+	require.True(t, errors.As(err, &actionErr))
+	assert.Equal(t, 3, actionErr.ExitCode)
+}
+
+func Test_Task_In_Container_Requires_Image(t *testing.T) {
+	yml := `
+tasks:
+  - name: build
+    container:
+      engine: podman
+    actions:
+      - go build
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	err := f.RunTask(context.Background(), "build", NewMockLogger())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "container image has not been set")
+}
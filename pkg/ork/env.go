@@ -1,10 +1,11 @@
-package main
+package ork
 
 import (
 	"bytes"
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -22,7 +23,7 @@ func (this Env) Apply(greedyEnvSubst bool) error {
 		for _, token := range parseEnvTokens(value, greedyEnvSubst) {
 			v, err := token.expand()
 			if err != nil {
-				return fmt.Errorf("key %s: %s: %v", key, value, err)
+				return fmt.Errorf("key %s: %s: %w", key, value, err)
 			}
 
 			val += v
@@ -33,6 +34,51 @@ func (this Env) Apply(greedyEnvSubst bool) error {
 	return nil
 }
 
+// the variable names in alphabetical order
+func (this Env) Keys() []string {
+	keys := []string{}
+	for key := range this {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// the environment of ork's process when it started
+var initialEnv = environ()
+
+func environ() map[string]string {
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		if pair := strings.SplitN(kv, "=", 2); len(pair) == 2 {
+			env[pair[0]] = pair[1]
+		}
+	}
+	return env
+}
+
+// the names of the variables that have been set (or changed) since ork started,
+// e.g. by the environments of the tasks (and their parents), the prompts or the
+// registered outputs of actions, along with the supplied keys (in alphabetical order)
+// these are the variables that need to be passed on to remote or isolated runners
+func changedEnvKeys(keys []string) []string {
+	changed := map[string]bool{}
+	for _, key := range keys {
+		changed[key] = true
+	}
+	for key, value := range environ() {
+		if initial, ok := initialEnv[key]; !ok || initial != value {
+			changed[key] = true
+		}
+	}
+	names := []string{}
+	for key := range changed {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // this represents a portion of an environment variable's value
 // that will either be executed and replaced with the execution output
 // or will just be used as is
@@ -0,0 +1,129 @@
+package ork
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	EXIT_CODE_FAILURE     = 1
+	EXIT_CODE_ORKFILE     = 2
+	EXIT_CODE_INTERRUPTED = 130
+)
+
+// returned when the workflow is interrupted by the user
+var ErrInterrupted = errors.New("C-c received")
+
+// the error of an action that could not be started or
+// that exited with a non-zero status
+type ActionError struct {
+	Action   string // the statement that was executed
+	ExitCode int    // the action's exit status (-1 if the action was not started)
+	Err      error
+}
+
+func (e *ActionError) Error() string {
+	if e.ExitCode < 0 {
+		return fmt.Sprintf("failed to start action: %v", e.Err)
+	}
+	return fmt.Sprintf("action failed: %v", e.Err)
+}
+
+func (e *ActionError) Unwrap() error { return e.Err }
+
+// the error of a task
+type TaskError struct {
+	Label string // the label of the failed task
+	Err   error
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("[%s] %v", e.Label, e.Err)
+}
+
+func (e *TaskError) Unwrap() error { return e.Err }
+
+// an error in the contents of the Orkfile or in the requested tasks
+type OrkfileError struct {
+	Err error
+}
+
+func (e *OrkfileError) Error() string { return e.Err.Error() }
+
+func (e *OrkfileError) Unwrap() error { return e.Err }
+
+// map the error to the process exit code of ork
+// the exit code of a failed action is passed through
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var failures MultiError
+	if errors.As(err, &failures) && len(failures) > 0 {
+		return ExitCode(failures[0])
+	}
+	if errors.Is(err, ErrInterrupted) {
+		return EXIT_CODE_INTERRUPTED
+	}
+	var oerr *OrkfileError
+	if errors.As(err, &oerr) {
+		return EXIT_CODE_ORKFILE
+	}
+	var aerr *ActionError
+	if errors.As(err, &aerr) && aerr.ExitCode > 0 {
+		return aerr.ExitCode
+	}
+	return EXIT_CODE_FAILURE
+}
+
+// a collection of the errors encountered during a run
+// that was allowed to continue past failures
+type MultiError []error
+
+func (m MultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, fmt.Sprintf("  - %v", err))
+	}
+	return fmt.Sprintf("%d failure(s):\n%s", len(m), strings.Join(msgs, "\n"))
+}
+
+// add the error to the collection (if not nil and not already added)
+// nested collections are flattened
+func (m *MultiError) Add(err error) {
+	if err == nil {
+		return
+	}
+	var nested MultiError
+	if !errors.As(err, &nested) {
+		nested = MultiError{err}
+	}
+	for _, e := range nested {
+		if !m.contains(e) {
+			*m = append(*m, e)
+		}
+	}
+}
+
+func (m MultiError) contains(err error) bool {
+	for _, e := range m {
+		if e == err {
+			return true
+		}
+	}
+	return false
+}
+
+// return nil if the collection is empty, the single error
+// if it contains only one, or the collection itself otherwise
+func (m MultiError) ErrorOrNil() error {
+	switch len(m) {
+	case 0:
+		return nil
+	case 1:
+		return m[0]
+	default:
+		return m
+	}
+}
@@ -0,0 +1,67 @@
+package ork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ExitCode(t *testing.T) {
+	kases := []struct {
+		description string
+		err         error
+		code        int
+	}{
+		{"no error", nil, 0},
+		{"generic error", errors.New("foo"), EXIT_CODE_FAILURE},
+		{"orkfile error", &OrkfileError{errors.New("foo")}, EXIT_CODE_ORKFILE},
+		{"interrupt", &TaskError{Label: "foo", Err: ErrInterrupted}, EXIT_CODE_INTERRUPTED},
+		{"action exit code", &TaskError{Label: "foo", Err: &ActionError{Action: "foo", ExitCode: 42}}, 42},
+		{"action not started", &TaskError{Label: "foo", Err: &ActionError{Action: "foo", ExitCode: -1}}, EXIT_CODE_FAILURE},
+		{"wrapped action", fmt.Errorf("env: %w", &ActionError{Action: "foo", ExitCode: 3}), 3},
+		{"first of many", MultiError{&ActionError{ExitCode: 5}, &OrkfileError{errors.New("foo")}}, 5},
+	}
+	for _, kase := range kases {
+		assert.Equal(t, kase.code, ExitCode(kase.err), kase.description)
+	}
+}
+
+func Test_TaskError_Carries_Label_Action_And_ExitCode(t *testing.T) {
+	yml := `
+tasks:
+  - name: foo
+    actions:
+      - bash -c "exit 7"
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	err := f.RunTask(context.Background(), "foo", NewMockLogger())
+
+	var terr *TaskError
+	require.ErrorAs(t, err, &terr)
+	assert.Equal(t, "foo", terr.Label)
+	var aerr *ActionError
+	require.ErrorAs(t, err, &aerr)
+	assert.Equal(t, `bash -c "exit 7"`, aerr.Action)
+	assert.Equal(t, 7, aerr.ExitCode)
+	assert.Equal(t, "[foo] action failed: exit status 7", err.Error())
+	assert.Equal(t, 7, ExitCode(err))
+}
+
+func Test_Orkfile_Errors_Map_To_Orkfile_ExitCode(t *testing.T) {
+	yml := `
+tasks:
+  - name: foo
+    depends_on:
+      - bar
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	assert.Equal(t, EXIT_CODE_ORKFILE, ExitCode(f.RunTask(context.Background(), "foo", NewMockLogger())))
+	assert.Equal(t, EXIT_CODE_ORKFILE, ExitCode(f.RunTask(context.Background(), "baz", NewMockLogger())))
+	assert.Equal(t, EXIT_CODE_ORKFILE, ExitCode(New().Parse([]byte("invalid yaml"))))
+}
@@ -0,0 +1,224 @@
+package ork
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// the typed events emitted by the execution engine during a run
+// every event can also be represented as a (flat) LogEvent
+type Event interface {
+	LogEvent() LogEvent
+}
+
+type RunStarted struct {
+	Time   time.Time
+	Labels []string // the requested tasks
+}
+
+type RunFinished struct {
+	Time     time.Time
+	Labels   []string
+	Duration time.Duration
+	Err      error
+}
+
+type TaskStarted struct {
+	Time   time.Time
+	Task   string
+	Parent string // the task that triggered the task's execution (if any)
+}
+
+// the task was not executed because one of its parents or dependencies failed
+type TaskSkipped struct {
+	Time   time.Time
+	Task   string
+	Parent string
+}
+
+type TaskFinished struct {
+	Time     time.Time
+	Task     string
+	Duration time.Duration
+	Err      error
+	UpToDate bool // nothing needed to be done (i.e. all the rendered files were up to date)
+}
+
+type RequirementFailed struct {
+	Time time.Time
+	Task string
+	Err  error
+}
+
+type ActionStarted struct {
+	Time   time.Time
+	Task   string
+	Action string
+}
+
+// a line of the output of a task's action
+type ActionOutput struct {
+	Time   time.Time
+	Task   string
+	Stream string // STREAM_STDOUT or STREAM_STDERR
+	Line   string
+}
+
+type ActionFinished struct {
+	Time     time.Time
+	Task     string
+	Action   string
+	Duration time.Duration
+	Err      error
+}
+
+type HookStarted struct {
+	Time   time.Time
+	Task   string
+	Hook   string // on_success or on_failure
+	Action string
+}
+
+type HookFinished struct {
+	Time     time.Time
+	Task     string
+	Hook     string
+	Action   string
+	Duration time.Duration
+	Err      error
+}
+
+func (e RunStarted) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_RUN_STARTED, Message: strings.Join(e.Labels, " ")}
+}
+
+func (e RunFinished) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_RUN_FINISHED, Message: strings.Join(e.Labels, " ")}.outcome(e.Duration, e.Err)
+}
+
+func (e TaskStarted) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_TASK_STARTED, Task: e.Task, Parent: e.Parent}
+}
+
+func (e TaskSkipped) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_TASK_SKIPPED, Task: e.Task, Parent: e.Parent, Status: STATUS_SKIPPED}
+}
+
+func (e TaskFinished) LogEvent() LogEvent {
+	le := LogEvent{Time: e.Time, Event: EVENT_TASK_FINISHED, Task: e.Task}.outcome(e.Duration, e.Err)
+	if e.UpToDate && e.Err == nil {
+		le.Status = STATUS_UP_TO_DATE
+	}
+	return le
+}
+
+func (e RequirementFailed) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_REQUIREMENT_FAILED, Task: e.Task, Error: e.Err.Error()}
+}
+
+func (e ActionStarted) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_ACTION_STARTED, Task: e.Task, Action: e.Action}
+}
+
+func (e ActionOutput) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_OUTPUT, Task: e.Task, Stream: e.Stream, Line: e.Line}
+}
+
+func (e ActionFinished) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_ACTION_FINISHED, Task: e.Task, Action: e.Action}.outcome(e.Duration, e.Err)
+}
+
+func (e HookStarted) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_HOOK_STARTED, Task: e.Task, Hook: e.Hook, Action: e.Action}
+}
+
+func (e HookFinished) LogEvent() LogEvent {
+	return LogEvent{Time: e.Time, Event: EVENT_HOOK_FINISHED, Task: e.Task, Hook: e.Hook, Action: e.Action}.outcome(e.Duration, e.Err)
+}
+
+// the consumers of the events of a run (e.g. loggers, summaries, reports or UIs)
+type Subscriber interface {
+	Notify(Event)
+}
+
+// a function that can be used as a subscriber
+type SubscriberFunc func(Event)
+
+func (f SubscriberFunc) Notify(e Event) { f(e) }
+
+// passes every published event to all of its subscribers (in the order they subscribed)
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers []Subscriber
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+func (b *EventBus) Subscribe(s Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, s)
+}
+
+// a nil bus discards all events
+func (b *EventBus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subscribers {
+		s.Notify(e)
+	}
+}
+
+// publish every line as a separate output event
+func (b *EventBus) publishOutput(task string, stream string, lines []string) {
+	for _, line := range lines {
+		b.Publish(ActionOutput{Time: time.Now(), Task: task, Stream: stream, Line: line})
+	}
+}
+
+// splits a stream of writes into lines
+// an incomplete line is kept until it is completed by a later write (or flushed)
+type lineBuffer struct {
+	pending strings.Builder
+}
+
+// the lines (without the newline) that are completed by the message
+func (b *lineBuffer) lines(msg string) []string {
+	lines := []string{}
+	for {
+		i := strings.IndexByte(msg, '\n')
+		if i < 0 {
+			b.pending.WriteString(msg)
+			return lines
+		}
+		b.pending.WriteString(msg[:i])
+		lines = append(lines, b.pending.String())
+		b.pending.Reset()
+		msg = msg[i+1:]
+	}
+}
+
+// the incomplete line (if any)
+func (b *lineBuffer) flush() []string {
+	if b.pending.Len() == 0 {
+		return nil
+	}
+	line := b.pending.String()
+	b.pending.Reset()
+	return []string{line}
+}
+
+// a bus whose events are presented by the logger (if it supports structured events)
+func loggerBus(logger Logger) *EventBus {
+	bus := NewEventBus()
+	if el, ok := logger.(EventLogger); ok {
+		bus.Subscribe(SubscriberFunc(func(e Event) { el.Event(e.LogEvent()) }))
+	}
+	return bus
+}
@@ -0,0 +1,100 @@
+package ork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EventBus_Notifies_All_Subscribers(t *testing.T) {
+	yml := `
+tasks:
+  - name: ci
+    depends_on:
+      - test
+    actions:
+      - echo ci
+  - name: test
+    actions:
+      - bash -c "echo testing; exit 3"
+    on_failure:
+      - echo failed
+`
+	a, b := []string{}, []string{}
+	f := New().
+		WithSubscriber(SubscriberFunc(func(e Event) { a = append(a, fmt.Sprintf("%T", e)) })).
+		WithSubscriber(SubscriberFunc(func(e Event) { b = append(b, fmt.Sprintf("%T", e)) }))
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.Error(t, f.Run(context.Background(), []string{"ci"}, NewMockLogger()))
+
+	assert.Equal(t, []string{
+		"ork.RunStarted",
+		"ork.TaskStarted",
+		"ork.ActionStarted",
+		"ork.ActionOutput",
+		"ork.ActionFinished",
+		"ork.HookStarted",
+		"ork.ActionOutput",
+		"ork.HookFinished",
+		"ork.TaskFinished",
+		"ork.TaskSkipped",
+		"ork.RunFinished",
+	}, a)
+	assert.Equal(t, a, b)
+}
+
+func Test_Events_As_LogEvents(t *testing.T) {
+	now := time.Now()
+	err := &TaskError{Label: "build", Err: &ActionError{Action: "make", ExitCode: 2, Err: errors.New("exit status 2")}}
+
+	e := ActionFinished{Time: now, Task: "build", Action: "make", Duration: 1500 * time.Millisecond, Err: err}.LogEvent()
+	assert.Equal(t, EVENT_ACTION_FINISHED, e.Event)
+	assert.Equal(t, now, e.Time)
+	assert.Equal(t, "build", e.Task)
+	assert.Equal(t, "make", e.Action)
+	assert.Equal(t, STATUS_FAILED, e.Status)
+	assert.Equal(t, 2, *e.ExitCode)
+	assert.Equal(t, 1.5, *e.Duration)
+	assert.Equal(t, err.Error(), e.Error)
+
+	e = TaskSkipped{Task: "deploy", Parent: "release"}.LogEvent()
+	assert.Equal(t, LogEvent{Event: EVENT_TASK_SKIPPED, Task: "deploy", Parent: "release", Status: STATUS_SKIPPED}, e)
+
+	e = RunFinished{Labels: []string{"a", "b"}}.LogEvent()
+	assert.Equal(t, "a b", e.Message)
+	assert.Equal(t, STATUS_OK, e.Status)
+	assert.Nil(t, e.ExitCode)
+}
+
+func Test_Output_Events_Are_Complete_Lines(t *testing.T) {
+	yml := `
+tasks:
+  - name: foo
+    actions:
+      - bash -c "printf foo; sleep 0.2; echo bar; printf baz"
+      - printf qux
+`
+	events := []string{}
+	f := New().WithSubscriber(SubscriberFunc(func(e Event) {
+		switch e := e.(type) {
+		case ActionOutput:
+			events = append(events, e.Line)
+		case ActionFinished:
+			events = append(events, "finished")
+		}
+	}))
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.NoError(t, f.Run(context.Background(), []string{"foo"}, NewMockLogger()))
+	// the incomplete line is published when the action finishes
+	assert.Equal(t, []string{"foobar", "baz", "finished", "qux", "finished"}, events)
+}
+
+func Test_EventBus_Nil_Discards_Events(t *testing.T) {
+	var bus *EventBus
+	assert.NotPanics(t, func() { bus.Publish(RunStarted{}) })
+}
@@ -0,0 +1,151 @@
+package ork
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DEFAULT_GENERATOR_ENV = "ORK_ITEM"
+
+	GENERATOR_FORMAT_LINES = "lines"
+	GENERATOR_FORMAT_JSON  = "json"
+)
+
+// a generator produces dynamic tasks from the output of an action
+// that is executed when the Orkfile is parsed
+type Generator struct {
+	Action string `yaml:"action"`
+	Env    string `yaml:"env"`
+	Format string `yaml:"format"`
+}
+
+// a generator can be declared either as a plain action statement
+// or as a mapping with the full set of attributes
+func (g *Generator) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&g.Action)
+	}
+	type plain Generator
+	return node.Decode((*plain)(g))
+}
+
+// execute the generator's action (in the supplied working directory)
+// and return one task per produced item
+func (g *Generator) Generate(chdir string) ([]*Task, error) {
+	buf := bytes.NewBuffer([]byte{})
+	if err := NewAction(g.Action).WithWorkingDirectory(chdir).WithStdout(buf).Execute(); err != nil {
+		return nil, fmt.Errorf("generator %s: %w", g.Action, err)
+	}
+
+	var tasks []*Task
+	var err error
+	switch g.Format {
+	case "", GENERATOR_FORMAT_LINES:
+		tasks = g.fromLines(buf.String())
+	case GENERATOR_FORMAT_JSON:
+		tasks, err = g.fromJSON(buf)
+	default:
+		err = fmt.Errorf("unknown format %s", g.Format)
+	}
+	if err == nil {
+		err = validateGeneratedNames(tasks)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generator %s: %v", g.Action, err)
+	}
+	return tasks, nil
+}
+
+// the generated names become part of the task labels, so they
+// can not contain the separator of the task groups
+func validateGeneratedNames(tasks []*Task) error {
+	for _, task := range tasks {
+		if strings.Contains(task.Name, DEFAULT_TASK_GROUP_SEP) {
+			return fmt.Errorf("generated task name %s contains %q", task.Name, DEFAULT_TASK_GROUP_SEP)
+		}
+	}
+	return nil
+}
+
+func (g *Generator) envKey() string {
+	if g.Env == "" {
+		return DEFAULT_GENERATOR_ENV
+	}
+	return g.Env
+}
+
+// every non-empty line of the output becomes a task named after the line
+func (g *Generator) fromLines(output string) []*Task {
+	tasks := []*Task{}
+	for _, line := range strings.Split(output, "\n") {
+		item := strings.TrimSpace(line)
+		if item == "" {
+			continue
+		}
+		tasks = append(tasks, &Task{
+			Name: item,
+			Env:  []Env{{g.envKey(): item}},
+		})
+	}
+	return tasks
+}
+
+// the output is a stream of JSON objects (or arrays of objects)
+// every object becomes a task named after its `name` field
+// the object's fields are exported as env variables
+func (g *Generator) fromJSON(output io.Reader) ([]*Task, error) {
+	items := []map[string]interface{}{}
+	dec := json.NewDecoder(output)
+	for {
+		var value interface{}
+		if err := dec.Decode(&value); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse output: %v", err)
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			items = append(items, v)
+		case []interface{}:
+			for _, e := range v {
+				obj, ok := e.(map[string]interface{})
+				if !ok {
+					return nil, errors.New("expected an array of JSON objects")
+				}
+				items = append(items, obj)
+			}
+		default:
+			return nil, errors.New("expected JSON objects")
+		}
+	}
+
+	tasks := []*Task{}
+	for _, item := range items {
+		name, ok := item["name"].(string)
+		if !ok || name == "" {
+			return nil, errors.New("JSON object does not contain a name")
+		}
+		raw, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		env := Env{g.envKey(): string(raw)}
+		for key, value := range item {
+			if s, ok := value.(string); ok {
+				env[key] = s
+			} else {
+				b, _ := json.Marshal(value)
+				env[key] = string(b)
+			}
+		}
+		tasks = append(tasks, &Task{Name: name, Env: []Env{env}})
+	}
+	return tasks, nil
+}
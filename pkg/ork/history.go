@@ -0,0 +1,294 @@
+package ork
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	DEFAULT_HISTORY_DIR  = ".ork/history" // relative to the Orkfile's directory
+	DEFAULT_HISTORY_SIZE = 100            // the number of runs to keep
+
+	historyIDFormat = "20060102T150405.000000"
+)
+
+// a local store of the past runs (one JSON file per run)
+type History struct {
+	dir  string
+	size int
+	args []string
+	logs bool
+}
+
+func NewHistory(dir string) *History {
+	return &History{dir: dir, size: DEFAULT_HISTORY_SIZE}
+}
+
+// the command-line arguments of the current invocation
+func (h *History) WithArgs(args []string) *History {
+	h.args = args
+	return h
+}
+
+// record the output of the tasks' actions along with the run
+func (h *History) WithLogs(logs bool) *History {
+	h.logs = logs
+	return h
+}
+
+// the number of runs to keep (older runs are removed)
+func (h *History) WithSize(size int) *History {
+	h.size = size
+	return h
+}
+
+// a new record for a run of the supplied tasks
+func (h *History) NewRun(orkfile []byte, labels []string) *RunRecord {
+	now := time.Now()
+	return &RunRecord{
+		ID:      now.Format(historyIDFormat),
+		Time:    now,
+		Args:    redactArgs(h.args),
+		Orkfile: fmt.Sprintf("%x", sha256.Sum256(orkfile)),
+		Labels:  labels,
+		logs:    h.logs,
+		running: map[string]*TaskRecord{},
+	}
+}
+
+// store the run and remove the oldest runs that exceed the history size
+func (h *History) Save(run *RunRecord) error {
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(h.dir, run.ID+".json"), b, 0644); err != nil {
+		return err
+	}
+	ids, err := h.ids()
+	if err != nil {
+		return err
+	}
+	for len(ids) > h.size && h.size > 0 {
+		if err := os.Remove(filepath.Join(h.dir, ids[0]+".json")); err != nil {
+			return err
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// the ids of all the stored runs (oldest first)
+func (h *History) ids() ([]string, error) {
+	entries, err := os.ReadDir(h.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && strings.HasSuffix(name, ".json") {
+			ids = append(ids, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// all the stored runs (oldest first)
+func (h *History) Runs() ([]*RunRecord, error) {
+	ids, err := h.ids()
+	if err != nil {
+		return nil, err
+	}
+	runs := []*RunRecord{}
+	for _, id := range ids {
+		run, err := h.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (h *History) Get(id string) (*RunRecord, error) {
+	contents, err := os.ReadFile(filepath.Join(h.dir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("run %s does not exist", id)
+	} else if err != nil {
+		return nil, err
+	}
+	run := &RunRecord{}
+	if err := json.Unmarshal(contents, run); err != nil {
+		return nil, fmt.Errorf("failed to read run %s: %v", id, err)
+	}
+	return run, nil
+}
+
+// the most recent run that failed
+func (h *History) LastFailed() (*RunRecord, error) {
+	runs, err := h.Runs()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].Status == STATUS_FAILED {
+			return runs[i], nil
+		}
+	}
+	return nil, errors.New("no failed runs found in history")
+}
+
+// a table of all the stored runs (most recent first)
+func (h *History) String() (string, error) {
+	runs, err := h.Runs()
+	if err != nil {
+		return "", err
+	}
+	buf := bytes.NewBuffer([]byte{})
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tSTATUS\tDURATION\tTASKS")
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			run.ID,
+			run.Time.Format("2006-01-02 15:04:05"),
+			run.Status,
+			formatDuration(seconds(run.Duration)),
+			strings.Join(run.Labels, " "))
+	}
+	w.Flush()
+	return buf.String(), nil
+}
+
+// a single run of ork
+type RunRecord struct {
+	ID       string        `json:"id"`
+	Time     time.Time     `json:"time"`
+	Args     []string      `json:"args"`
+	Orkfile  string        `json:"orkfile"` // the sha256 of the Orkfile's contents
+	Labels   []string      `json:"labels"`  // the requested tasks
+	Status   string        `json:"status"`
+	Duration float64       `json:"duration"` // in seconds
+	ExitCode int           `json:"exit_code"`
+	Error    string        `json:"error,omitempty"`
+	Tasks    []*TaskRecord `json:"tasks"`
+
+	mu      sync.Mutex
+	logs    bool
+	running map[string]*TaskRecord
+}
+
+// the outcome of a task executed during a run
+type TaskRecord struct {
+	Label    string   `json:"label"`
+	Status   string   `json:"status"`
+	Duration float64  `json:"duration"` // in seconds
+	ExitCode *int     `json:"exit_code,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Output   []string `json:"output,omitempty"`
+}
+
+func (r *RunRecord) Notify(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch e := e.(type) {
+	case TaskStarted:
+		task := &TaskRecord{Label: e.Task}
+		r.Tasks = append(r.Tasks, task)
+		r.running[e.Task] = task
+	case TaskSkipped:
+		r.Tasks = append(r.Tasks, &TaskRecord{Label: e.Task, Status: STATUS_SKIPPED})
+	case ActionOutput:
+		if task, ok := r.running[e.Task]; ok && r.logs {
+			task.Output = append(task.Output, redact(e.Line))
+		}
+	case TaskFinished:
+		if task, ok := r.running[e.Task]; ok {
+			le := e.LogEvent()
+			task.Status = le.Status
+			task.ExitCode = le.ExitCode
+			task.Error = redact(le.Error)
+			task.Duration = e.Duration.Seconds()
+			delete(r.running, e.Task)
+		}
+	case RunFinished:
+		// the outcome of the run
+		r.Duration = e.Duration.Seconds()
+		r.Status = status(e.Err)
+		if e.Err != nil {
+			r.ExitCode = ExitCode(e.Err)
+			r.Error = redact(e.Err.Error())
+		}
+	}
+}
+
+// the labels of the tasks that failed during the run
+// (not including the tasks that were skipped as a result)
+func (r *RunRecord) FailedTasks() []string {
+	labels := []string{}
+	for _, task := range r.Tasks {
+		if task.Status == STATUS_FAILED {
+			labels = append(labels, task.Label)
+		}
+	}
+	return labels
+}
+
+// the details of the run along with its tasks (and their output, if recorded)
+func (r *RunRecord) String() string {
+	buf := bytes.NewBuffer([]byte{})
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "run:\t%s\n", r.ID)
+	fmt.Fprintf(w, "time:\t%s\n", r.Time.Format(time.RFC3339))
+	fmt.Fprintf(w, "args:\t%s\n", strings.Join(r.Args, " "))
+	fmt.Fprintf(w, "orkfile:\t%s\n", r.Orkfile)
+	fmt.Fprintf(w, "status:\t%s\n", r.Status)
+	fmt.Fprintf(w, "exit code:\t%d\n", r.ExitCode)
+	fmt.Fprintf(w, "duration:\t%s\n", formatDuration(seconds(r.Duration)))
+	if r.Error != "" {
+		fmt.Fprintf(w, "error:\t%s\n", strings.ReplaceAll(r.Error, "\n", " "))
+	}
+	w.Flush()
+
+	buf.WriteString("\n")
+	w = tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tSTATUS\tDURATION\tEXIT CODE")
+	for _, task := range r.Tasks {
+		duration, code := "-", "-"
+		if task.Status != STATUS_SKIPPED {
+			duration = formatDuration(seconds(task.Duration))
+		}
+		if task.ExitCode != nil {
+			code = fmt.Sprintf("%d", *task.ExitCode)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", task.Label, task.Status, duration, code)
+	}
+	w.Flush()
+
+	for _, task := range r.Tasks {
+		if len(task.Output) > 0 {
+			fmt.Fprintf(buf, "\n==> [%s]\n%s\n", task.Label, strings.Join(task.Output, "\n"))
+		}
+	}
+	return buf.String()
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
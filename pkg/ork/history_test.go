@@ -0,0 +1,107 @@
+package ork
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_History_Records_Runs(t *testing.T) {
+	yml := `
+tasks:
+  - name: ci
+    depends_on:
+      - test
+    actions:
+      - echo ci
+  - name: test
+    actions:
+      - echo testing
+      - bash -c "exit 3"
+`
+	history := NewHistory(t.TempDir()).WithArgs([]string{"ci"}).WithLogs(true)
+	f := New().WithHistory(history)
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.Error(t, f.Run(context.Background(), []string{"ci"}, NewMockLogger()))
+
+	runs, err := history.Runs()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(runs))
+	run := runs[0]
+	assert.Equal(t, []string{"ci"}, run.Args)
+	assert.Equal(t, []string{"ci"}, run.Labels)
+	assert.Equal(t, STATUS_FAILED, run.Status)
+	assert.Equal(t, 3, run.ExitCode)
+	assert.Len(t, run.Orkfile, 64)
+	require.Equal(t, 2, len(run.Tasks))
+	assert.Equal(t, "test", run.Tasks[0].Label)
+	assert.Equal(t, STATUS_FAILED, run.Tasks[0].Status)
+	require.NotNil(t, run.Tasks[0].ExitCode)
+	assert.Equal(t, 3, *run.Tasks[0].ExitCode)
+	assert.Equal(t, []string{"testing"}, run.Tasks[0].Output)
+	assert.Equal(t, "ci", run.Tasks[1].Label)
+	assert.Equal(t, STATUS_SKIPPED, run.Tasks[1].Status)
+	assert.Equal(t, []string{"test"}, run.FailedTasks())
+
+	last, err := history.LastFailed()
+	require.NoError(t, err)
+	assert.Equal(t, run.ID, last.ID)
+
+	details := run.String()
+	assert.Contains(t, details, "status:     failed")
+	assert.Contains(t, details, "==> [test]\ntesting\n")
+}
+
+func Test_History_Is_Pruned(t *testing.T) {
+	history := NewHistory(t.TempDir()).WithSize(2)
+	ids := []string{}
+	for i := 0; i < 3; i++ {
+		run := history.NewRun([]byte{}, []string{"foo"})
+		run.ID = strings.Repeat("a", i+1) // make sure that the ids are distinct and ordered
+		run.Notify(RunFinished{})
+		require.NoError(t, history.Save(run))
+		ids = append(ids, run.ID)
+	}
+	runs, err := history.Runs()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(runs))
+	assert.Equal(t, ids[1:], []string{runs[0].ID, runs[1].ID})
+
+	_, err = history.Get("does_not_exist")
+	assert.ErrorContains(t, err, "run does_not_exist does not exist")
+	_, err = history.LastFailed()
+	assert.ErrorContains(t, err, "no failed runs")
+}
+
+func Test_History_Redacts_Secrets(t *testing.T) {
+	yml := `
+tasks:
+  - name: deploy
+    prompt:
+      - name: ORK_TEST_HISTORY_TOKEN
+        type: password
+    actions:
+      - bash -c "echo token=$ORK_TEST_HISTORY_TOKEN; exit 1"
+`
+	defer os.Unsetenv("ORK_TEST_HISTORY_TOKEN")
+	args := []string{"--param", "ORK_TEST_HISTORY_TOKEN=t0ps3cr3t", "--param=STAGE=ci", "deploy"}
+	history := NewHistory(t.TempDir()).WithArgs(args).WithLogs(true)
+	f := New().
+		WithHistory(history).
+		WithStdin(strings.NewReader("")).
+		WithParams(map[string]string{"ORK_TEST_HISTORY_TOKEN": "t0ps3cr3t", "STAGE": "ci"})
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.Error(t, f.Run(context.Background(), []string{"deploy"}, NewMockLogger()))
+
+	runs, err := history.Runs()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(runs))
+	assert.Equal(t, []string{"--param", "ORK_TEST_HISTORY_TOKEN=***", "--param=STAGE=***", "deploy"}, runs[0].Args)
+	assert.Equal(t, []string{"token=***"}, runs[0].Tasks[0].Output)
+	// the arguments of the invocation are left intact
+	assert.Equal(t, "ORK_TEST_HISTORY_TOKEN=t0ps3cr3t", args[1])
+}
@@ -1,4 +1,4 @@
-package main
+package ork
 
 import (
 	"fmt"
@@ -19,19 +19,29 @@ func (i Inventory) populate(tasks []*Task, prefix string) error {
 		if prefix != "" {
 			taskName = strings.Join([]string{prefix, taskName}, DEFAULT_TASK_GROUP_SEP)
 		}
+		if err := task.validateStderr(); err != nil {
+			return &OrkfileError{fmt.Errorf("[%s] %v", taskName, err)}
+		}
+		if err := task.validateBuiltins(); err != nil {
+			return &OrkfileError{fmt.Errorf("[%s] %v", taskName, err)}
+		}
 		// add task
 		if err := i.Add(taskName, task); err != nil {
 			return err
 		}
 		// add generated tasks
-		if err := i.populate(task.DynamicTasks, taskName); err != nil {
+		dtasks, err := task.GeneratedTasks()
+		if err != nil {
+			return fmt.Errorf("[%s] %v", taskName, err)
+		}
+		if err := i.populate(dtasks, taskName); err != nil {
 			return err
 		}
 
 		// add nested tasks
-		if len(task.DynamicTasks) > 0 {
+		if len(dtasks) > 0 {
 			// add all nested tasks under each dynamic task
-			for _, dtask := range task.DynamicTasks {
+			for _, dtask := range dtasks {
 				pref := strings.Join([]string{taskName, dtask.Name}, DEFAULT_TASK_GROUP_SEP)
 				if err := i.populate(task.Tasks, pref); err != nil {
 					return err
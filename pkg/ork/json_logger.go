@@ -0,0 +1,183 @@
+package ork
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/apsdehal/go-logger"
+)
+
+// the structured events recorded during a run
+const (
+	EVENT_LOG                = "log"
+	EVENT_OUTPUT             = "output"
+	EVENT_RUN_STARTED        = "run_started"
+	EVENT_RUN_FINISHED       = "run_finished"
+	EVENT_TASK_STARTED       = "task_started"
+	EVENT_TASK_FINISHED      = "task_finished"
+	EVENT_TASK_SKIPPED       = "task_skipped"
+	EVENT_ACTION_STARTED     = "action_started"
+	EVENT_ACTION_FINISHED    = "action_finished"
+	EVENT_HOOK_STARTED       = "hook_started"
+	EVENT_HOOK_FINISHED      = "hook_finished"
+	EVENT_REQUIREMENT_FAILED = "requirement_failed"
+	EVENT_RUN_SUMMARY        = "run_summary"
+
+	STATUS_OK         = "ok"
+	STATUS_FAILED     = "failed"
+	STATUS_SKIPPED    = "skipped"
+	STATUS_UP_TO_DATE = "up-to-date"
+
+	STREAM_STDOUT = "stdout"
+	STREAM_STDERR = "stderr"
+)
+
+type LogEvent struct {
+	Time     time.Time      `json:"time"`
+	Event    string         `json:"event"`
+	Level    string         `json:"level,omitempty"`
+	Message  string         `json:"message,omitempty"`
+	Task     string         `json:"task,omitempty"`
+	Parent   string         `json:"parent,omitempty"` // the task that triggered the task's execution
+	Action   string         `json:"action,omitempty"`
+	Hook     string         `json:"hook,omitempty"` // on_success or on_failure
+	Stream   string         `json:"stream,omitempty"`
+	Line     string         `json:"line,omitempty"`
+	Status   string         `json:"status,omitempty"`
+	ExitCode *int           `json:"exit_code,omitempty"`
+	Duration *float64       `json:"duration,omitempty"` // in seconds
+	Error    string         `json:"error,omitempty"`
+	Tasks    []*TaskSummary `json:"tasks,omitempty"` // the tasks of a run summary
+}
+
+// loggers that can record structured events
+type EventLogger interface {
+	Event(LogEvent)
+	// is the logger currently producing structured output?
+	Structured() bool
+}
+
+func isStructured(l Logger) bool {
+	el, ok := l.(EventLogger)
+	return ok && el.Structured()
+}
+
+// set the event's status, exit code and error according to the outcome
+// along with its duration
+func (e LogEvent) outcome(duration time.Duration, err error) LogEvent {
+	d := duration.Seconds()
+	e.Duration = &d
+	if err == nil {
+		e.Status = STATUS_OK
+		return e
+	}
+	e.Status = STATUS_FAILED
+	e.Error = err.Error()
+	code := ExitCode(err)
+	e.ExitCode = &code
+	return e
+}
+
+// writes every log message, event and output line as a JSON object per line
+type JSONLogger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level logger.LogLevel
+
+	lmu   sync.Mutex
+	lines map[string]*lineBuffer // the output written so far per stream
+}
+
+func NewJSONLogger(out io.Writer) *JSONLogger {
+	lines := map[string]*lineBuffer{STREAM_STDOUT: {}, STREAM_STDERR: {}}
+	return &JSONLogger{out: out, level: logger.InfoLevel, lines: lines}
+}
+
+func (l *JSONLogger) Event(e LogEvent) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(append(b, '\n'))
+}
+
+func (l *JSONLogger) log(lvl logger.LogLevel, name string, msg string) {
+	if lvl <= l.level {
+		l.Event(LogEvent{Event: EVENT_LOG, Level: name, Message: msg})
+	}
+}
+
+func (l *JSONLogger) Fatal(msg string) { l.log(logger.CriticalLevel, "critical", msg) }
+
+func (l *JSONLogger) Fatalf(msg string, a ...interface{}) { l.Fatal(fmt.Sprintf(msg, a...)) }
+
+func (l *JSONLogger) Error(msg string) { l.log(logger.ErrorLevel, LOG_LEVEL_ERROR, msg) }
+
+func (l *JSONLogger) Errorf(msg string, a ...interface{}) { l.Error(fmt.Sprintf(msg, a...)) }
+
+func (l *JSONLogger) Info(msg string) { l.log(logger.InfoLevel, LOG_LEVEL_INFO, msg) }
+
+func (l *JSONLogger) Infof(msg string, a ...interface{}) { l.Info(fmt.Sprintf(msg, a...)) }
+
+func (l *JSONLogger) Debug(msg string) { l.log(logger.DebugLevel, LOG_LEVEL_DEBUG, msg) }
+
+func (l *JSONLogger) Debugf(msg string, a ...interface{}) { l.Debug(fmt.Sprintf(msg, a...)) }
+
+func (l *JSONLogger) Trace(msg string) { l.log(TraceLevel, LOG_LEVEL_TRACE, msg) }
+
+func (l *JSONLogger) Tracef(msg string, a ...interface{}) { l.Trace(fmt.Sprintf(msg, a...)) }
+
+func (l *JSONLogger) Structured() bool { return true }
+
+// a message is always recorded as complete lines
+func (l *JSONLogger) Output(msg string) { l.output(STREAM_STDOUT, msg, true) }
+
+func (l *JSONLogger) OutputStderr(msg string) { l.output(STREAM_STDERR, msg, true) }
+
+// record every line of the message as a separate output event
+// an incomplete line is kept until it is completed (unless the message is complete)
+func (l *JSONLogger) output(stream string, msg string, complete bool) {
+	l.lmu.Lock()
+	defer l.lmu.Unlock()
+	lines := l.lines[stream].lines(msg)
+	if complete {
+		lines = append(lines, l.lines[stream].flush()...)
+	}
+	for _, line := range lines {
+		l.Event(LogEvent{Event: EVENT_OUTPUT, Stream: stream, Line: line})
+	}
+}
+
+// the written bytes are a stream (i.e. a line may span multiple writes)
+func (l *JSONLogger) Write(p []byte) (n int, err error) {
+	l.output(STREAM_STDOUT, string(p), false)
+	return len(p), nil
+}
+
+func (l *JSONLogger) SetLogLevel(level string) error {
+	lvl, ok := logLevels[level]
+	if !ok {
+		return fmt.Errorf("unknown log level: %s", level)
+	}
+	l.level = lvl
+	return nil
+}
+
+func (l *JSONLogger) GetLogLevel() logger.LogLevel {
+	return l.level
+}
+
+func (l *JSONLogger) SetFormat(format string) error {
+	if format != LOG_FORMAT_JSON {
+		return fmt.Errorf("unsupported log format: %s", format)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package ork
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseEvents(t *testing.T, buf *bytes.Buffer) []LogEvent {
+	events := []LogEvent{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e LogEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &e), line)
+		events = append(events, e)
+	}
+	return events
+}
+
+func Test_JSONLogger_Records_Run_Events(t *testing.T) {
+	yml := `
+tasks:
+  - name: dep
+    actions:
+      - echo dep
+  - name: foo
+    depends_on:
+      - dep
+    require:
+      exists:
+        - PATH
+    actions:
+      - bash -c "echo out; echo err >&2; exit 3"
+    on_failure:
+      - echo failure
+`
+	buf := bytes.NewBuffer([]byte{})
+	log := NewJSONLogger(buf)
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.Error(t, f.RunTask(context.Background(), "foo", log))
+
+	type summary struct {
+		event, task, status string
+		exitCode            int
+	}
+	actual := []summary{}
+	for _, e := range parseEvents(t, buf) {
+		s := summary{event: e.Event, task: e.Task, status: e.Status}
+		if e.Event == EVENT_OUTPUT {
+			s.status = e.Stream + ":" + e.Line
+		}
+		if e.ExitCode != nil {
+			s.exitCode = *e.ExitCode
+		}
+		if e.Event == EVENT_TASK_FINISHED || e.Event == EVENT_ACTION_FINISHED || e.Event == EVENT_HOOK_FINISHED {
+			assert.NotNil(t, e.Duration, e.Event)
+		}
+		if e.Event == EVENT_LOG {
+			continue
+		}
+		actual = append(actual, s)
+	}
+	// stdout and stderr are copied concurrently
+	assert.ElementsMatch(t, []summary{
+		{EVENT_OUTPUT, "foo", "stdout:out", 0},
+		{EVENT_OUTPUT, "foo", "stderr:err", 0},
+	}, actual[8:10])
+	actual = append(actual[:8], actual[10:]...)
+	assert.Equal(t, []summary{
+		{EVENT_RUN_STARTED, "", "", 0},
+		{EVENT_TASK_STARTED, "dep", "", 0},
+		{EVENT_ACTION_STARTED, "dep", "", 0},
+		{EVENT_OUTPUT, "dep", "stdout:dep", 0},
+		{EVENT_ACTION_FINISHED, "dep", STATUS_OK, 0},
+		{EVENT_TASK_FINISHED, "dep", STATUS_OK, 0},
+		{EVENT_TASK_STARTED, "foo", "", 0},
+		{EVENT_ACTION_STARTED, "foo", "", 0},
+		{EVENT_ACTION_FINISHED, "foo", STATUS_FAILED, 3},
+		{EVENT_HOOK_STARTED, "foo", "", 0},
+		{EVENT_OUTPUT, "foo", "stdout:failure", 0},
+		{EVENT_HOOK_FINISHED, "foo", STATUS_OK, 0},
+		{EVENT_TASK_FINISHED, "foo", STATUS_FAILED, 3},
+		{EVENT_RUN_FINISHED, "", STATUS_FAILED, 3},
+	}, actual)
+}
+
+func Test_JSONLogger_Records_Requirement_Failures(t *testing.T) {
+	yml := `
+tasks:
+  - name: foo
+    require:
+      exists:
+        - VAR_DOES_NOT_EXIST_1234
+`
+	buf := bytes.NewBuffer([]byte{})
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.Error(t, f.RunTask(context.Background(), "foo", NewJSONLogger(buf)))
+
+	events := parseEvents(t, buf)
+	require.Equal(t, 5, len(events))
+	assert.Equal(t, EVENT_REQUIREMENT_FAILED, events[2].Event)
+	assert.Contains(t, events[2].Error, "VAR_DOES_NOT_EXIST_1234")
+}
+
+func Test_JSONLogger_Write_Joins_Split_Lines(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	l := NewJSONLogger(buf)
+	l.Write([]byte("fo"))
+	l.Write([]byte("o\nba"))
+	l.Write([]byte("r\n"))
+	l.Write([]byte("ba"))
+	l.Output("z\n")
+
+	lines := []string{}
+	for _, e := range parseEvents(t, buf) {
+		lines = append(lines, e.Line)
+	}
+	assert.Equal(t, []string{"foo", "bar", "baz"}, lines)
+}
+
+func Test_JSONLogger_Log_Levels(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	log := NewJSONLogger(buf)
+	log.Debug("hidden")
+	log.Infof("info %d", 1)
+	require.NoError(t, log.SetLogLevel(LOG_LEVEL_DEBUG))
+	log.Debug("visible")
+	log.Output("a\nb\n")
+
+	events := parseEvents(t, buf)
+	require.Equal(t, 4, len(events))
+	assert.Equal(t, LogEvent{Event: EVENT_LOG, Level: LOG_LEVEL_INFO, Message: "info 1"}, withoutTime(events[0]))
+	assert.Equal(t, LogEvent{Event: EVENT_LOG, Level: LOG_LEVEL_DEBUG, Message: "visible"}, withoutTime(events[1]))
+	assert.Equal(t, LogEvent{Event: EVENT_OUTPUT, Stream: STREAM_STDOUT, Line: "a"}, withoutTime(events[2]))
+	assert.Equal(t, LogEvent{Event: EVENT_OUTPUT, Stream: STREAM_STDOUT, Line: "b"}, withoutTime(events[3]))
+}
+
+func Test_OrkLogger_SetFormat(t *testing.T) {
+	l, err := NewLogger()
+	require.NoError(t, err)
+	assert.False(t, isStructured(l))
+	assert.NoError(t, l.SetFormat(LOG_FORMAT_JSON))
+	assert.True(t, isStructured(l))
+	assert.NoError(t, l.SetFormat(LOG_FORMAT_TEXT))
+	assert.False(t, isStructured(l))
+	assert.ErrorContains(t, l.SetFormat("xml"), "unknown log format: xml")
+}
+
+func withoutTime(e LogEvent) LogEvent {
+	e.Time = time.Time{}
+	return e
+}
@@ -0,0 +1,178 @@
+package ork
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// a junit report of a run: every executed task is a test suite
+// and every one of its actions (and hooks) is a test case
+type JUnitReport struct {
+	mu      sync.Mutex
+	start   time.Time
+	suites  []*junitSuite
+	running map[string]*junitSuite
+}
+
+type junitReportXML struct {
+	XMLName  xml.Name      `xml:"testsuites"`
+	Name     string        `xml:"name,attr"`
+	Tests    int           `xml:"tests,attr"`
+	Failures int           `xml:"failures,attr"`
+	Skipped  int           `xml:"skipped,attr"`
+	Time     float64       `xml:"time,attr"`
+	Suites   []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string       `xml:"name,attr"`
+	Tests     int          `xml:"tests,attr"`
+	Failures  int          `xml:"failures,attr"`
+	Skipped   int          `xml:"skipped,attr"`
+	Time      float64      `xml:"time,attr"`
+	Timestamp string       `xml:"timestamp,attr"`
+	Cases     []*junitCase `xml:"testcase"`
+
+	current *junitCase // the currently running action
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+
+	stdout strings.Builder
+	stderr strings.Builder
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func NewJUnitReport() *JUnitReport {
+	return &JUnitReport{start: time.Now(), running: map[string]*junitSuite{}}
+}
+
+func (r *JUnitReport) Notify(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := event.LogEvent()
+	switch e.Event {
+	case EVENT_TASK_STARTED:
+		suite := &junitSuite{Name: e.Task, Timestamp: e.Time.Format(time.RFC3339)}
+		r.suites = append(r.suites, suite)
+		r.running[e.Task] = suite
+	case EVENT_TASK_SKIPPED:
+		r.suites = append(r.suites, &junitSuite{
+			Name:      e.Task,
+			Tests:     1,
+			Skipped:   1,
+			Timestamp: e.Time.Format(time.RFC3339),
+			Cases:     []*junitCase{{Name: e.Task, ClassName: e.Task, Skipped: &struct{}{}}},
+		})
+	case EVENT_ACTION_STARTED, EVENT_HOOK_STARTED:
+		if suite, ok := r.running[e.Task]; ok {
+			name := e.Action
+			if e.Hook != "" {
+				name = fmt.Sprintf("%s: %s", e.Hook, e.Action)
+			}
+			suite.current = &junitCase{Name: name, ClassName: e.Task}
+			suite.Cases = append(suite.Cases, suite.current)
+		}
+	case EVENT_OUTPUT:
+		if suite, ok := r.running[e.Task]; ok && suite.current != nil {
+			if e.Stream == STREAM_STDERR {
+				suite.current.stderr.WriteString(redact(e.Line) + "\n")
+			} else {
+				suite.current.stdout.WriteString(redact(e.Line) + "\n")
+			}
+		}
+	case EVENT_ACTION_FINISHED, EVENT_HOOK_FINISHED:
+		if suite, ok := r.running[e.Task]; ok && suite.current != nil {
+			suite.current.finish(e)
+			suite.current = nil
+		}
+	case EVENT_TASK_FINISHED:
+		if suite, ok := r.running[e.Task]; ok {
+			// a task can also fail outside of its actions (e.g. requirements)
+			if e.Status == STATUS_FAILED && !suite.hasFailures() {
+				c := &junitCase{Name: e.Task, ClassName: e.Task}
+				c.finish(e)
+				suite.Cases = append(suite.Cases, c)
+			}
+			if e.Duration != nil {
+				suite.Time = *e.Duration
+			}
+			delete(r.running, e.Task)
+		}
+	}
+}
+
+func (c *junitCase) finish(e LogEvent) {
+	if e.Duration != nil {
+		c.Time = *e.Duration
+	}
+	c.SystemOut = c.stdout.String()
+	c.SystemErr = c.stderr.String()
+	if e.Status == STATUS_FAILED {
+		kind := "error"
+		if e.ExitCode != nil {
+			kind = fmt.Sprintf("exit status %d", *e.ExitCode)
+		}
+		c.Failure = &junitFailure{Message: redact(e.Error), Type: kind, Text: c.SystemErr}
+	}
+}
+
+func (s *junitSuite) hasFailures() bool {
+	for _, c := range s.Cases {
+		if c.Failure != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// return the report in XML format
+func (r *JUnitReport) Marshal() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := junitReportXML{Name: "ork", Time: time.Since(r.start).Seconds(), Suites: r.suites}
+	for _, suite := range r.suites {
+		suite.Tests = len(suite.Cases)
+		suite.Failures, suite.Skipped = 0, 0
+		for _, c := range suite.Cases {
+			if c.Failure != nil {
+				suite.Failures++
+			}
+			if c.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+	b, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
+
+func (r *JUnitReport) WriteFile(path string) error {
+	b, err := r.Marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,89 @@
+package ork
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_JUnitReport_Records_Executed_Tasks(t *testing.T) {
+	yml := `
+tasks:
+  - name: ci
+    depends_on:
+      - lint
+      - test
+    actions:
+      - echo ci
+  - name: lint
+    actions:
+      - echo linting
+    on_success:
+      - echo linted
+  - name: test
+    actions:
+      - bash -c "echo broken >&2; exit 3"
+`
+	path := filepath.Join(t.TempDir(), "report.xml")
+	f := New().WithKeepGoing(true).WithJUnitReport(path)
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.Error(t, f.Run(context.Background(), []string{"ci"}, NewMockLogger()))
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	report := junitReportXML{}
+	require.NoError(t, xml.Unmarshal(contents, &report))
+
+	assert.Equal(t, 4, report.Tests)
+	assert.Equal(t, 1, report.Failures)
+	assert.Equal(t, 1, report.Skipped)
+	require.Equal(t, 3, len(report.Suites))
+
+	lint := report.Suites[0]
+	assert.Equal(t, "lint", lint.Name)
+	require.Equal(t, 2, len(lint.Cases))
+	assert.Equal(t, "echo linting", lint.Cases[0].Name)
+	assert.Equal(t, "lint", lint.Cases[0].ClassName)
+	assert.Equal(t, "linting\n", lint.Cases[0].SystemOut)
+	assert.Nil(t, lint.Cases[0].Failure)
+	assert.Equal(t, "on_success: echo linted", lint.Cases[1].Name)
+
+	test := report.Suites[1]
+	assert.Equal(t, "test", test.Name)
+	assert.Equal(t, 1, test.Failures)
+	require.Equal(t, 1, len(test.Cases))
+	require.NotNil(t, test.Cases[0].Failure)
+	assert.Equal(t, "exit status 3", test.Cases[0].Failure.Type)
+	assert.Equal(t, "broken\n", test.Cases[0].SystemErr)
+
+	ci := report.Suites[2]
+	assert.Equal(t, "ci", ci.Name)
+	assert.Equal(t, 1, ci.Skipped)
+	require.Equal(t, 1, len(ci.Cases))
+	assert.NotNil(t, ci.Cases[0].Skipped)
+}
+
+func Test_JUnitReport_Records_Task_Failures(t *testing.T) {
+	r := NewJUnitReport()
+	r.Notify(TaskStarted{Task: "deploy"})
+	r.Notify(RequirementFailed{Task: "deploy", Err: errors.New("requirement not met")})
+	r.Notify(TaskFinished{Task: "deploy", Duration: 500 * time.Millisecond, Err: errors.New("requirement not met")})
+
+	contents, err := r.Marshal()
+	require.NoError(t, err)
+	report := junitReportXML{}
+	require.NoError(t, xml.Unmarshal(contents, &report))
+	require.Equal(t, 1, len(report.Suites))
+	require.Equal(t, 1, len(report.Suites[0].Cases))
+	failure := report.Suites[0].Cases[0].Failure
+	require.NotNil(t, failure)
+	assert.Equal(t, "requirement not met", failure.Message)
+	assert.Equal(t, 0.5, report.Suites[0].Time)
+}
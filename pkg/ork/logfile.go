@@ -0,0 +1,77 @@
+package ork
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DEFAULT_LOG_FILE_ROTATIONS = 5 // the number of older log files to keep per task
+)
+
+// the path of the file in which the output of the task's actions is recorded (if any)
+// the task's log_file takes precedence over the log directory
+func (lt *LabeledTask) logFilePath() string {
+	if lt.LogFile != "" {
+		return os.ExpandEnv(lt.LogFile)
+	}
+	if lt.logDir != "" {
+		name := strings.ReplaceAll(lt.label, string(os.PathSeparator), "_")
+		return filepath.Join(lt.logDir, name+".log")
+	}
+	return ""
+}
+
+// the log files that have been opened within a run
+// a log file is only rotated the first time that it is opened (e.g. parent tasks are
+// executed along with each of their children), after which the output is appended to it
+type logFiles map[string]bool
+
+func (l logFiles) open(path string) (*os.File, error) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if l[key] {
+		return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	}
+	file, err := openLogFile(path, DEFAULT_LOG_FILE_ROTATIONS)
+	if err == nil {
+		l[key] = true
+	}
+	return file, err
+}
+
+// create a new log file in the supplied path
+// an existing log file is rotated (i.e. path.1, path.2 etc.) and
+// only the supplied number of older log files is kept
+func openLogFile(path string, rotations int) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := rotateLogFile(path, rotations); err != nil {
+		return nil, fmt.Errorf("failed to rotate log file %s: %v", path, err)
+	}
+	return os.Create(path)
+}
+
+func rotateLogFile(path string, rotations int) error {
+	rotated := func(i int) string {
+		if i == 0 {
+			return path
+		}
+		return fmt.Sprintf("%s.%d", path, i)
+	}
+	if err := os.Remove(rotated(rotations)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := rotations - 1; i >= 0; i-- {
+		if err := os.Rename(rotated(i), rotated(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
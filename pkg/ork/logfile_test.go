@@ -0,0 +1,105 @@
+package ork
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Task_LogDir_Records_Output(t *testing.T) {
+	dir := t.TempDir()
+	yml := `
+tasks:
+  - name: build
+    depends_on:
+      - deps
+    actions:
+      - echo building $RUN
+      - bash -c "echo oops >&2"
+  - name: deps
+    actions:
+      - echo deps
+`
+	for i := 1; i <= DEFAULT_LOG_FILE_ROTATIONS+2; i++ {
+		require.NoError(t, os.Setenv("RUN", fmt.Sprintf("%d", i)))
+		f := New().WithLogDir(dir)
+		require.NoError(t, f.Parse([]byte(yml)))
+		require.NoError(t, f.Run(context.Background(), []string{"build"}, NewMockLogger()))
+	}
+
+	contents, err := os.ReadFile(filepath.Join(dir, "build.log"))
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("building %d\noops\n", DEFAULT_LOG_FILE_ROTATIONS+2), string(contents))
+	contents, err = os.ReadFile(filepath.Join(dir, "deps.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "deps\n", string(contents))
+
+	// older runs are rotated
+	contents, err = os.ReadFile(filepath.Join(dir, "build.log.1"))
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("building %d\noops\n", DEFAULT_LOG_FILE_ROTATIONS+1), string(contents))
+	assert.FileExists(t, filepath.Join(dir, fmt.Sprintf("build.log.%d", DEFAULT_LOG_FILE_ROTATIONS)))
+	assert.NoFileExists(t, filepath.Join(dir, fmt.Sprintf("build.log.%d", DEFAULT_LOG_FILE_ROTATIONS+1)))
+}
+
+func Test_Task_LogFile_Takes_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "custom.log")
+	yml := fmt.Sprintf(`
+tasks:
+  - name: build
+    log_file: %s
+    actions:
+      - echo building
+    on_success:
+      - echo built
+`, path)
+	f := New().WithLogDir(dir)
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+	require.NoError(t, f.Run(context.Background(), []string{"build"}, log))
+
+	// the output is still presented
+	assert.Equal(t, []string{"building\n", "built\n"}, log.Outputs())
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "building\nbuilt\n", string(contents))
+	assert.NoFileExists(t, filepath.Join(dir, "build.log"))
+}
+
+func Test_Task_LogFile_Is_Rotated_Once_Per_Run(t *testing.T) {
+	dir := t.TempDir()
+	yml := `
+tasks:
+  - name: a
+    actions:
+      - echo a $RUN
+    tasks:
+      - name: b
+        actions:
+          - echo b
+      - name: c
+        actions:
+          - echo c
+`
+	for i := 1; i <= 2; i++ {
+		t.Setenv("RUN", fmt.Sprintf("%d", i))
+		f := New().WithLogDir(dir)
+		require.NoError(t, f.Parse([]byte(yml)))
+		require.NoError(t, f.Run(context.Background(), []string{"a.b", "a.c"}, NewMockLogger()))
+	}
+
+	// the parent is executed along with each of its children
+	contents, err := os.ReadFile(filepath.Join(dir, "a.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "a 2\na 2\n", string(contents))
+	contents, err = os.ReadFile(filepath.Join(dir, "a.log.1"))
+	require.NoError(t, err)
+	assert.Equal(t, "a 1\na 1\n", string(contents))
+	assert.NoFileExists(t, filepath.Join(dir, "a.log.2"))
+}
@@ -0,0 +1,243 @@
+package ork
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apsdehal/go-logger"
+)
+
+const (
+	LOG_LEVEL_INFO  = "info"
+	LOG_LEVEL_ERROR = "error"
+	LOG_LEVEL_DEBUG = "debug"
+	LOG_LEVEL_TRACE = "trace"
+
+	LOG_FORMAT_TEXT = "text"
+	LOG_FORMAT_JSON = "json"
+)
+
+// the most verbose level (beyond go-logger's levels)
+// trace messages are presented as debug messages
+const TraceLevel = logger.DebugLevel + 1
+
+var (
+	logLevels = map[string]logger.LogLevel{
+		LOG_LEVEL_INFO:  logger.InfoLevel,
+		LOG_LEVEL_DEBUG: logger.DebugLevel,
+		LOG_LEVEL_ERROR: logger.ErrorLevel,
+		LOG_LEVEL_TRACE: TraceLevel,
+	}
+)
+
+type Logger interface {
+	Fatal(string)
+	Fatalf(string, ...interface{})
+
+	Error(string)
+	Errorf(string, ...interface{})
+
+	Info(string)
+	Infof(string, ...interface{})
+
+	Debug(string)
+	Debugf(string, ...interface{})
+
+	// the internals of the execution (more verbose than debug)
+	Trace(string)
+	Tracef(string, ...interface{})
+
+	Output(string)
+	// output produced by an action in its standard error
+	OutputStderr(string)
+
+	// implements the io.Writer interface
+	Write(p []byte) (n int, err error)
+
+	SetLogLevel(string) error
+	GetLogLevel() logger.LogLevel
+	SetFormat(string) error
+}
+
+// loggers that know whether their output is presented in a terminal
+type TerminalLogger interface {
+	// is the stream (STREAM_STDOUT or STREAM_STDERR) written to a terminal?
+	Terminal(stream string) bool
+}
+
+func isTerminalOutput(l Logger, stream string) bool {
+	tl, ok := l.(TerminalLogger)
+	return ok && tl.Terminal(stream)
+}
+
+type OrkLogger struct {
+	level logger.LogLevel
+	*logger.Logger
+	json   *JSONLogger // all messages are delegated to this logger in json format
+	stdout io.Writer   // log messages and output
+	stderr io.Writer   // error output
+}
+
+// a logger that writes to the standard output and error of the process
+func NewLogger() (Logger, error) {
+	return NewLoggerTo(os.Stdout, os.Stderr)
+}
+
+// a logger that writes the log messages and the output of the actions to stdout
+// and the error output of the actions to stderr
+func NewLoggerTo(stdout io.Writer, stderr io.Writer) (Logger, error) {
+	l, err := logger.New("ork", 1, stdout)
+	if err != nil {
+		return nil, err
+	}
+	l.SetFormat("[%{level}] %{message}")
+	l.SetLogLevel(logger.InfoLevel)
+	return &OrkLogger{Logger: l, level: logger.InfoLevel, stdout: stdout, stderr: stderr}, nil
+}
+
+func (l *OrkLogger) SetLogLevel(level string) error {
+	lvl, ok := logLevels[level]
+	if !ok {
+		return fmt.Errorf("unknown log level: %s", level)
+	}
+	if lvl == TraceLevel {
+		l.Logger.SetLogLevel(logger.DebugLevel)
+	} else {
+		l.Logger.SetLogLevel(lvl)
+	}
+	l.level = lvl
+	if l.json != nil {
+		return l.json.SetLogLevel(level)
+	}
+	return nil
+}
+
+func (l *OrkLogger) SetFormat(format string) error {
+	switch format {
+	case LOG_FORMAT_TEXT:
+		l.json = nil
+	case LOG_FORMAT_JSON:
+		l.json = NewJSONLogger(l.stdout)
+		l.json.level = l.level
+	default:
+		return fmt.Errorf("unknown log format: %s", format)
+	}
+	return nil
+}
+
+func (l *OrkLogger) Structured() bool {
+	return l.json != nil
+}
+
+func (l *OrkLogger) Terminal(stream string) bool {
+	w := l.stdout
+	if stream == STREAM_STDERR {
+		w = l.stderr
+	}
+	f, ok := w.(*os.File)
+	return ok && IsTerminal(f)
+}
+
+func (l *OrkLogger) Event(e LogEvent) {
+	if l.json != nil {
+		l.json.Event(e)
+	}
+}
+
+func (l *OrkLogger) Fatal(msg string) {
+	if l.json != nil {
+		l.json.Fatal(msg)
+		return
+	}
+	l.Logger.Fatal(msg)
+}
+
+func (l *OrkLogger) Fatalf(msg string, a ...interface{}) {
+	l.Fatal(fmt.Sprintf(msg, a...))
+}
+
+func (l *OrkLogger) Error(msg string) {
+	if l.json != nil {
+		l.json.Error(msg)
+		return
+	}
+	l.Logger.Error(msg)
+}
+
+func (l *OrkLogger) Errorf(msg string, a ...interface{}) {
+	l.Error(fmt.Sprintf(msg, a...))
+}
+
+func (l *OrkLogger) Info(msg string) {
+	if l.json != nil {
+		l.json.Info(msg)
+		return
+	}
+	l.Logger.Info(msg)
+}
+
+func (l *OrkLogger) Infof(msg string, a ...interface{}) {
+	l.Info(fmt.Sprintf(msg, a...))
+}
+
+func (l *OrkLogger) Debug(msg string) {
+	if l.json != nil {
+		l.json.Debug(msg)
+		return
+	}
+	l.Logger.Debug(msg)
+}
+
+func (l *OrkLogger) Debugf(msg string, a ...interface{}) {
+	l.Debug(fmt.Sprintf(msg, a...))
+}
+
+func (l *OrkLogger) Trace(msg string) {
+	if l.json != nil {
+		l.json.Trace(msg)
+		return
+	}
+	if l.level >= TraceLevel {
+		l.Logger.Debug(msg)
+	}
+}
+
+func (l *OrkLogger) Tracef(msg string, a ...interface{}) {
+	l.Trace(fmt.Sprintf(msg, a...))
+}
+
+func (l *OrkLogger) GetLogLevel() logger.LogLevel {
+	return l.level
+}
+
+func (l *OrkLogger) Write(p []byte) (n int, err error) {
+	l.Output(string(p))
+	return len(p), nil
+}
+
+func (l *OrkLogger) Output(message string) {
+	if l.json != nil {
+		l.json.Output(message)
+		return
+	}
+	fmt.Fprint(l.stdout, message)
+}
+
+func (l *OrkLogger) OutputStderr(message string) {
+	if l.json != nil {
+		l.json.OutputStderr(message)
+		return
+	}
+	fmt.Fprint(l.stderr, message)
+}
+
+// an io.Writer that routes the standard error of actions through the logger
+type StderrWriter struct {
+	Logger
+}
+
+func (w StderrWriter) Write(p []byte) (n int, err error) {
+	w.OutputStderr(string(p))
+	return len(p), nil
+}
@@ -0,0 +1,334 @@
+// Package ork loads Orkfiles and executes their tasks, e.g.:
+//
+//	f := ork.New().WithKeepGoing(true).WithSubscriber(subscriber)
+//	if err := f.Load(ork.DEFAULT_ORKFILE); err != nil {
+//		return err
+//	}
+//	logger, err := ork.NewLoggerTo(stdout, stderr)
+//	...
+//	return f.Run(ctx, []string{"build"}, logger)
+package ork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DEFAULT_ORKFILE = "Orkfile.yml"
+)
+
+// the version of ork (set by the executable)
+var Version string
+
+type Orkfile struct {
+	Default string  `yaml:"default"`
+	Tasks   []*Task `yaml:"tasks"`
+
+	inventory   Inventory
+	dir         string // the directory of the Orkfile (if loaded from a file)
+	stdin       io.Reader
+	keepGoing   bool
+	gracePeriod time.Duration
+	outputMode  string
+	summary     bool
+	junitReport string
+	history     *History
+	logDir      string
+	trace       string
+	env         Env
+	params      map[string]string
+	yes         bool
+	dryRun      bool
+	subscribers []Subscriber
+	contents    []byte
+}
+
+func Read(path string) (contents []byte, err error) {
+	contents, err = ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	return
+}
+
+func New() *Orkfile { return &Orkfile{gracePeriod: DEFAULT_GRACE_PERIOD, outputMode: OUTPUT_PLAIN} }
+
+func (f *Orkfile) WithStdin(stdin io.Reader) *Orkfile {
+	f.stdin = stdin
+	return f
+}
+
+// continue executing the remaining tasks and dependencies after a failure
+// all the encountered errors will be returned at the end of the run
+func (f *Orkfile) WithKeepGoing(keepGoing bool) *Orkfile {
+	f.keepGoing = keepGoing
+	return f
+}
+
+// the time to wait for a running action to exit after the workflow has been
+// interrupted before the action is killed
+func (f *Orkfile) WithGracePeriod(gracePeriod time.Duration) *Orkfile {
+	f.gracePeriod = gracePeriod
+	return f
+}
+
+// the presentation of the output of the tasks' actions (see OUTPUT_*)
+func (f *Orkfile) WithOutputMode(mode string) *Orkfile {
+	f.outputMode = mode
+	return f
+}
+
+// print a summary of the executed tasks (with timings) at the end of the run
+func (f *Orkfile) WithSummary(summary bool) *Orkfile {
+	f.summary = summary
+	return f
+}
+
+// write a junit report of the run to the supplied path
+func (f *Orkfile) WithJUnitReport(path string) *Orkfile {
+	f.junitReport = path
+	return f
+}
+
+// record the output of every task in a separate file in the supplied directory
+func (f *Orkfile) WithLogDir(dir string) *Orkfile {
+	f.logDir = dir
+	return f
+}
+
+// export a trace of the run to the supplied file or OTLP/HTTP endpoint
+func (f *Orkfile) WithTrace(destination string) *Orkfile {
+	f.trace = destination
+	return f
+}
+
+// apply the environment before running any tasks
+func (f *Orkfile) WithEnv(env Env) *Orkfile {
+	f.env = env
+	return f
+}
+
+// the parameters of the run (available to templates as {{ param "key" }})
+func (f *Orkfile) WithParams(params map[string]string) *Orkfile {
+	f.params = params
+	return f
+}
+
+// assume yes in confirmations (and accept the default values of prompts in non-interactive mode)
+func (f *Orkfile) WithYes(yes bool) *Orkfile {
+	f.yes = yes
+	return f
+}
+
+// describe the actions of the tasks (and the files that they would change) instead of executing them
+func (f *Orkfile) WithDryRun(dryRun bool) *Orkfile {
+	f.dryRun = dryRun
+	return f
+}
+
+// pass the events of every run to the subscriber
+func (f *Orkfile) WithSubscriber(subscriber Subscriber) *Orkfile {
+	f.subscribers = append(f.subscribers, subscriber)
+	return f
+}
+
+// record the runs in the supplied history
+func (f *Orkfile) WithHistory(history *History) *Orkfile {
+	f.history = history
+	return f
+}
+
+// read the orkfile in the path, parse it and populate the task inventory
+func (f *Orkfile) Load(path string) error {
+	contents, err := Read(path)
+	if err != nil {
+		return &OrkfileError{fmt.Errorf("failed to find Orkfile in path %s", path)}
+	}
+	if f.dir, err = filepath.Abs(filepath.Dir(path)); err != nil {
+		return &OrkfileError{err}
+	}
+	if err := f.Parse(contents); err != nil {
+		return &OrkfileError{fmt.Errorf("failed to parse Orkfile: %v", err)}
+	}
+	return nil
+}
+
+// parse the orkfile and populate the task inventory
+func (f *Orkfile) Parse(contents []byte) error {
+	f.contents = contents
+	if err := yaml.Unmarshal(contents, f); err != nil {
+		return &OrkfileError{err}
+	}
+	// populate the task inventory
+	f.inventory = Inventory{}
+	if err := f.inventory.Populate(f.Tasks); err != nil {
+		return &OrkfileError{err}
+	}
+	for _, task := range f.inventory {
+		task.project = f.dir
+	}
+	return nil
+}
+
+// run the requested tasks (or the default task) and produce
+// the requested summaries and reports
+func (f *Orkfile) Run(ctx context.Context, labels []string, logger Logger) (err error) {
+	if err := f.env.Apply(false); err != nil {
+		return &OrkfileError{fmt.Errorf("failed to apply environment: %w", err)}
+	}
+	requested := labels
+	if len(requested) == 0 && f.Default != "" {
+		requested = []string{f.Default}
+	}
+
+	events := loggerBus(logger)
+	for _, s := range f.subscribers {
+		events.Subscribe(s)
+	}
+	if f.history != nil {
+		run := f.history.NewRun(f.contents, requested)
+		defer func() {
+			if err := f.history.Save(run); err != nil {
+				logger.Errorf("failed to record run in history: %v", err)
+			}
+		}()
+		events.Subscribe(run)
+	}
+	if f.summary {
+		summary := NewRunSummary()
+		defer func() {
+			// the summary is recorded as a single event in the structured log
+			if el, ok := logger.(EventLogger); ok && el.Structured() {
+				el.Event(summary.LogEvent())
+			} else {
+				logger.Output(summary.String())
+			}
+		}()
+		events.Subscribe(summary)
+	}
+	if f.junitReport != "" {
+		report := NewJUnitReport()
+		defer func() {
+			if err := report.WriteFile(f.junitReport); err != nil {
+				logger.Errorf("failed to write junit report: %v", err)
+			}
+		}()
+		events.Subscribe(report)
+	}
+	if f.trace != "" {
+		tracer := NewTracer()
+		defer func() {
+			if err := tracer.Export(f.trace); err != nil {
+				logger.Errorf("failed to export trace: %v", err)
+			}
+		}()
+		events.Subscribe(tracer)
+	}
+
+	// the end of the run needs to be published before the subscribers' results are produced
+	start := time.Now()
+	events.Publish(RunStarted{Time: start, Labels: requested})
+	defer func() {
+		events.Publish(RunFinished{Time: time.Now(), Labels: requested, Duration: time.Since(start), Err: err})
+	}()
+	return f.run(ctx, labels, logger, events)
+}
+
+// run the requested tasks (or the default task)
+func (f *Orkfile) run(ctx context.Context, labels []string, logger Logger, events *EventBus) error {
+	if len(labels) == 0 {
+		return f.runDefault(ctx, logger, events)
+	} else {
+		var failures MultiError
+		// the tasks are executed at most once within the run
+		// (and their log files are rotated at most once)
+		done := outcomes{}
+		logs := logFiles{}
+		for _, label := range labels {
+			if err := f.runTask(ctx, label, logger, events, done, logs); err != nil {
+				if !f.keepGoing || ctx.Err() != nil {
+					return err
+				}
+				failures.Add(err)
+			}
+		}
+
+		return failures.ErrorOrNil()
+	}
+}
+
+// run the requested task (as a run of a single task)
+func (f *Orkfile) RunTask(ctx context.Context, label string, logger Logger) error {
+	return f.Run(ctx, []string{label}, logger)
+}
+
+func (f *Orkfile) runTask(ctx context.Context, label string, logger Logger, events *EventBus, done outcomes, logs logFiles) error {
+	task := f.inventory.Find(label)
+	if task == nil {
+		return &OrkfileError{fmt.Errorf("task %s does not exist", label)}
+	}
+
+	return task.
+		WithStdin(f.stdin).
+		WithKeepGoing(f.keepGoing).
+		WithGracePeriod(f.gracePeriod).
+		WithOutputMode(f.outputMode).
+		WithLogDir(f.logDir).
+		WithParams(f.params).
+		WithYes(f.yes).
+		WithDryRun(f.dryRun).
+		WithEventBus(events).
+		withOutcomes(done).
+		withLogFiles(logs).
+		Execute(ctx, f.inventory, logger)
+}
+
+// run the default task (if any)
+func (f *Orkfile) RunDefault(ctx context.Context, logger Logger) error {
+	return f.Run(ctx, nil, logger)
+}
+
+func (f *Orkfile) runDefault(ctx context.Context, logger Logger, events *EventBus) error {
+	if f.Default == "" {
+		return &OrkfileError{errors.New("default task has not been set")}
+	}
+	return f.runTask(ctx, f.Default, logger, events, nil, nil)
+}
+
+// return info for the requested task
+func (f *Orkfile) Info(label string) (info string) {
+	if task := f.inventory.Find(label); task != nil {
+		desc := task.Description
+		if desc == "" {
+			desc = "<no description>"
+		}
+		info = fmt.Sprintf("[%s] %s", label, desc)
+	}
+	return
+}
+
+func (f *Orkfile) GetTasks(sel TaskSelector) []*LabeledTask {
+	return f.inventory.Tasks(sel)
+}
+
+func (f *Orkfile) Labels(sel TaskSelector) []string {
+	return f.inventory.Labels(sel)
+}
+
+// return all the actionable labels in the orkfile in alphabetical order
+func AllLabels(f *Orkfile) []string {
+	labels := f.Labels(Actionable)
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i] < labels[j]
+	})
+	return labels
+}
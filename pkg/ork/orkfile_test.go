@@ -1,10 +1,11 @@
-package main
+package ork
 
 import (
 	"bytes"
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
@@ -176,10 +177,10 @@ tasks:
   - name: foo
     expand_env: false
     actions:
-      - bash -c "for f in $(ls -1 main.go); do echo $f; done;"
+      - bash -c "for f in $(ls -1 orkfile.go); do echo $f; done;"
 `,
 			task:    "foo",
-			outputs: []string{"main.go"},
+			outputs: []string{"orkfile.go"},
 		},
 		// ===================================
 		{
@@ -528,6 +529,31 @@ tasks:
 	assert.NotContains(t, outputs, "this will not be in the output")
 }
 
+func Test_Interrupted_Task_Executes_Its_Failure_Hooks(t *testing.T) {
+	dir := t.TempDir()
+	yml := `
+tasks:
+  - name: serve
+    actions:
+      - sleep 5
+    on_failure:
+      - bash -c "sleep 0.3; echo cleanup > ` + dir + `/hook"
+`
+	f := New().WithStdin(strings.NewReader("")).WithGracePeriod(2 * time.Second)
+	require.NoError(t, f.Parse([]byte(yml)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		cancel()
+	}()
+	assert.Error(t, f.RunTask(ctx, "serve", NewMockLogger()))
+
+	contents, err := os.ReadFile(dir + "/hook")
+	require.NoError(t, err)
+	assert.Equal(t, "cleanup\n", string(contents))
+}
+
 func Test_Orkfile_Task_Info(t *testing.T) {
 	yml := `
 tasks:
@@ -713,7 +739,7 @@ tasks:
 }
 
 func Test_Read(t *testing.T) {
-	contents, err := Read("Orkfile.yml")
+	contents, err := Read("../../Orkfile.yml")
 	assert.NoError(t, err)
 	assert.NoError(t, New().Parse(contents))
 }
@@ -804,3 +830,442 @@ tasks:
 		assert.True(t, strings.HasPrefix(actual[i], expected[i]), actual[i])
 	}
 }
+
+func Test_Task_Generation_From_Action(t *testing.T) {
+	yml := `
+tasks:
+  - name: services
+    generate_from:
+      action: bash -c "echo api; echo web"
+      env: SERVICE
+    tasks:
+      - name: build
+        actions:
+          - echo "building ${SERVICE}"
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+
+	labels := AllLabels(f)
+	assert.Equal(t, []string{"services.api.build", "services.web.build"}, labels)
+
+	log := NewMockLogger()
+	assert.NoError(t, f.RunTask(context.Background(), "services.web.build", log))
+	assert.Equal(t, []string{"building web\n"}, log.Outputs())
+}
+
+func Test_Task_Generation_From_Action_Defaults_And_Static_Tasks(t *testing.T) {
+	yml := `
+tasks:
+  - name: gen
+    generate_from: echo dynamic
+    generate:
+      - name: static
+        env:
+          - ORK_ITEM: static
+    tasks:
+      - name: show
+        actions:
+          - echo $ORK_ITEM
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	assert.Equal(t, []string{"gen.dynamic.show", "gen.static.show"}, AllLabels(f))
+
+	log := NewMockLogger()
+	assert.NoError(t, f.RunTask(context.Background(), "gen.dynamic.show", log))
+	assert.Equal(t, []string{"dynamic\n"}, log.Outputs())
+}
+
+func Test_Task_Generation_From_Action_JSON(t *testing.T) {
+	yml := `
+tasks:
+  - name: deploy
+    generate_from:
+      action: echo '[{"name":"production","url":"https://prod"},{"name":"staging","url":"https://staging"}]'
+      format: json
+    tasks:
+      - name: ping
+        actions:
+          - echo $url
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	assert.Equal(t, []string{"deploy.production.ping", "deploy.staging.ping"}, AllLabels(f))
+
+	log := NewMockLogger()
+	assert.NoError(t, f.RunTask(context.Background(), "deploy.staging.ping", log))
+	assert.Equal(t, []string{"https://staging\n"}, log.Outputs())
+}
+
+func Test_Task_Generation_From_Action_In_Working_Dir(t *testing.T) {
+	dir := t.TempDir()
+	for _, service := range []string{"api", "web"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, service), 0755))
+	}
+	yml := fmt.Sprintf(`
+tasks:
+  - name: services
+    working_dir: %s
+    generate_from: ls
+    tasks:
+      - name: build
+        actions:
+          - echo $ORK_ITEM
+`, dir)
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	assert.Equal(t, []string{"services.api.build", "services.web.build"}, AllLabels(f))
+}
+
+func Test_Task_Generation_From_Action_Errors(t *testing.T) {
+	kases := []struct {
+		description string
+		generator   string
+		errmsg      string
+	}{
+		{"action fails", "a_non_existent_program", "generator a_non_existent_program"},
+		{"invalid json", "{action: echo foo, format: json}", "failed to parse output"},
+		{"json without name", `{action: 'echo ''{"url":"foo"}''', format: json}`, "does not contain a name"},
+		{"unknown format", "{action: echo foo, format: xml}", "unknown format xml"},
+		{"name with separator", "echo api.v2", `generated task name api.v2 contains "."`},
+		{"json name with separator", `{action: 'echo ''{"name":"a.b"}''', format: json}`, `generated task name a.b contains "."`},
+	}
+	for _, kase := range kases {
+		yml := fmt.Sprintf("tasks:\n  - name: foo\n    generate_from: %s\n", kase.generator)
+		assert.ErrorContains(t, New().Parse([]byte(yml)), kase.errmsg, kase.description)
+	}
+}
+
+func Test_Orkfile_Action_IgnoreError(t *testing.T) {
+	yml := `
+tasks:
+  - name: foo
+    actions:
+      - run: bash -c "exit 3"
+        ignore_error: true
+      - echo foo
+    on_success:
+      - echo success
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+
+	assert.NoError(t, f.RunTask(context.Background(), "foo", log))
+	assert.Equal(t, []string{"foo\n", "success\n"}, log.Outputs())
+	require.Equal(t, 1, len(log.Logs(logger.ErrorLevel)))
+	assert.Contains(t, log.Logs(logger.ErrorLevel)[0], "[foo] action failed: exit status 3")
+}
+
+func Test_Orkfile_Task_IgnoreError(t *testing.T) {
+	yml := `
+tasks:
+  - name: foo
+    depends_on:
+      - bar
+    actions:
+      - echo foo
+  - name: bar
+    ignore_error: true
+    actions:
+      - bash -c "exit 1"
+      - echo bar
+    on_failure:
+      - echo failure
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+
+	assert.NoError(t, f.RunTask(context.Background(), "foo", log))
+	assert.Equal(t, []string{"failure\n", "foo\n"}, log.Outputs())
+	require.Equal(t, 1, len(log.Logs(logger.ErrorLevel)))
+	assert.Contains(t, log.Logs(logger.ErrorLevel)[0], "[bar] ignoring task failure")
+}
+
+func Test_Orkfile_KeepGoing_Aggregates_Failures(t *testing.T) {
+	yml := `
+tasks:
+  - name: check-all
+    depends_on:
+      - check.a
+      - check.b
+      - check.c
+    actions:
+      - echo all
+  - name: check.a
+    actions:
+      - bash -c "exit 2"
+  - name: check.b
+    actions:
+      - echo b
+  - name: check.c
+    actions:
+      - bash -c "exit 4"
+`
+	// without keep going, the run stops at the first failure
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+	err := f.RunTask(context.Background(), "check-all", log)
+	assert.EqualError(t, err, "[check.a] action failed: exit status 2")
+	assert.Empty(t, log.Outputs())
+
+	// keep going executes all the dependencies but not the task itself
+	// and the tasks that are requested again are not re-executed
+	f = New().WithKeepGoing(true)
+	require.NoError(t, f.Parse([]byte(yml)))
+	log = NewMockLogger()
+	err = f.Run(context.Background(), []string{"check-all", "check.b", "check.c"}, log)
+	require.Error(t, err)
+	var failures MultiError
+	require.ErrorAs(t, err, &failures)
+	assert.Equal(t, []string{"b\n"}, log.Outputs())
+	assert.Equal(t, 2, len(failures))
+	assert.Equal(t, `2 failure(s):
+  - [check.a] action failed: exit status 2
+  - [check.c] action failed: exit status 4`, err.Error())
+}
+
+func Test_Orkfile_Tasks_Are_Executed_Once_Per_Run(t *testing.T) {
+	yml := `
+tasks:
+  - name: a
+    depends_on:
+      - c
+    actions:
+      - echo a
+  - name: b
+    depends_on:
+      - c
+    actions:
+      - echo b
+  - name: c
+    actions:
+      - echo c
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+	require.NoError(t, f.Run(context.Background(), []string{"a", "b", "c"}, log))
+	assert.Equal(t, []string{"c\n", "a\n", "b\n"}, log.Outputs())
+
+	// every run starts afresh
+	log = NewMockLogger()
+	require.NoError(t, f.Run(context.Background(), []string{"a"}, log))
+	assert.Equal(t, []string{"c\n", "a\n"}, log.Outputs())
+}
+
+func Test_Orkfile_Action_Register_Output(t *testing.T) {
+	yml := `
+tasks:
+  - name: container
+    actions:
+      - run: echo c0ffee
+        register: CONTAINER_ID
+      - echo "started ${CONTAINER_ID}"
+    on_success:
+      - echo "hook ${CONTAINER_ID}"
+  - name: stop
+    depends_on:
+      - container
+    actions:
+      - echo "stopping ${CONTAINER_ID}"
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+
+	require.NoError(t, f.RunTask(context.Background(), "stop", log))
+	assert.Equal(t, []string{"started c0ffee\n", "hook c0ffee\n", "stopping c0ffee\n"}, log.Outputs())
+}
+
+func Test_Orkfile_Action_Register_JSON_Output(t *testing.T) {
+	yml := `
+tasks:
+  - name: inspect
+    actions:
+      - run: echo '{"id":"c0ffee","state":{"running":true},"host-port":8080}'
+        register_json: CONTAINER
+      - echo "${CONTAINER_ID} ${CONTAINER_STATE} ${CONTAINER_HOST_PORT}"
+      - echo "${CONTAINER}"
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+
+	require.NoError(t, f.RunTask(context.Background(), "inspect", log))
+	assert.Equal(t, []string{
+		"c0ffee {running:true} 8080\n",
+		"{id:c0ffee,state:{running:true},host-port:8080}\n",
+	}, log.Outputs())
+}
+
+func Test_Orkfile_Action_Register_Invalid_JSON_Output(t *testing.T) {
+	yml := `
+tasks:
+  - name: inspect
+    actions:
+      - run: echo foo
+        register_json: FOO
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+
+	assert.ErrorContains(t, f.RunTask(context.Background(), "inspect", log), "[inspect] failed to register FOO: invalid JSON output")
+}
+
+func Test_Orkfile_Task_Stderr_Modes(t *testing.T) {
+	kases := []struct {
+		mode    string
+		outputs []string
+		stderr  []string
+	}{
+		{"", []string{"out\n"}, []string{"err\n"}},
+		{STDERR_SEPARATE, []string{"out\n"}, []string{"err\n"}},
+		{STDERR_MERGE, []string{"out\n", "err\n"}, []string{}},
+		{STDERR_SUPPRESS, []string{"out\n"}, []string{}},
+	}
+	for _, kase := range kases {
+		yml := fmt.Sprintf(`
+tasks:
+  - name: foo
+    stderr: "%s"
+    actions:
+      - bash -c "echo out; sleep 0.05; echo err >&2"
+`, kase.mode)
+		f := New()
+		require.NoError(t, f.Parse([]byte(yml)), kase.mode)
+		log := NewMockLogger()
+		require.NoError(t, f.RunTask(context.Background(), "foo", log), kase.mode)
+		assert.Equal(t, kase.outputs, log.Outputs(), kase.mode)
+		assert.Equal(t, kase.stderr, log.Stderr(), kase.mode)
+	}
+
+	// unknown modes are rejected when the Orkfile is parsed
+	err := New().Parse([]byte(`
+tasks:
+  - name: foo
+    tasks:
+      - name: bar
+        stderr: discard
+`))
+	var oerr *OrkfileError
+	require.ErrorAs(t, err, &oerr)
+	assert.EqualError(t, err, "[foo.bar] unknown stderr mode: discard (one of separate, merge, suppress)")
+}
+
+func Test_Orkfile_Load_And_Run_With_Options(t *testing.T) {
+	path := t.TempDir() + "/Orkfile.yml"
+	require.NoError(t, os.WriteFile(path, []byte(`
+tasks:
+  - name: greet
+    actions:
+      - echo hello $ORK_TEST_NAME
+      - bash -c "echo bye >&2"
+`), os.ModePerm))
+
+	events := []string{}
+	subscriber := SubscriberFunc(func(e Event) {
+		if _, ok := e.(ActionOutput); !ok {
+			events = append(events, e.LogEvent().Event+":"+e.LogEvent().Task)
+		}
+	})
+	f := New().WithEnv(Env{"ORK_TEST_NAME": "world"}).WithSubscriber(subscriber)
+	require.NoError(t, f.Load(path))
+	assert.Equal(t, []string{"greet"}, AllLabels(f))
+
+	stdout := bytes.NewBuffer([]byte{})
+	stderr := bytes.NewBuffer([]byte{})
+	log, err := NewLoggerTo(stdout, stderr)
+	require.NoError(t, err)
+	require.NoError(t, f.Run(context.Background(), []string{"greet"}, log))
+
+	assert.Contains(t, stdout.String(), "[INFO] [greet] echo hello $ORK_TEST_NAME")
+	assert.Contains(t, stdout.String(), "hello world\n")
+	assert.Equal(t, "bye\n", stderr.String())
+	assert.Equal(t, []string{
+		"run_started:",
+		"task_started:greet",
+		"action_started:greet",
+		"action_finished:greet",
+		"action_started:greet",
+		"action_finished:greet",
+		"task_finished:greet",
+		"run_finished:",
+	}, events)
+}
+
+func Test_Orkfile_Load_Errors(t *testing.T) {
+	err := New().Load("does_not_exist.yml")
+	assert.ErrorContains(t, err, "failed to find Orkfile in path does_not_exist.yml")
+	assert.Equal(t, EXIT_CODE_ORKFILE, ExitCode(err))
+}
+
+func Test_Orkfile_Dry_Run_Changes_Nothing(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/config.tmpl", []byte("{{ .Task }}"), 0644))
+	yml := fmt.Sprintf(`
+tasks:
+  - name: deploy
+    working_dir: %s
+    confirm: Deploy?
+    render:
+      - src: config.tmpl
+        dst: config
+    actions:
+      - ork:touch deployed
+      - run: echo $ORK_TEST_DRY_RUN_VERSION
+        register: ORK_TEST_DRY_RUN_VERSION
+      - run: sleep 10
+        background: true
+    on_success:
+      - touch hooked
+`, dir)
+	f := New().WithDryRun(true).WithStdin(bytes.NewReader(nil))
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "deploy", log))
+
+	assert.Equal(t, []string{
+		"would touch " + dir + "/deployed\n",
+		"would run: echo \n",
+		"would run: sleep 10\n",
+		"would run: touch hooked\n",
+	}, log.Outputs())
+	assert.Contains(t, log.Logs(logger.InfoLevel), "[deploy] would render config.tmpl -> config")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+}
+
+func Test_Orkfile_RunTask_Is_A_Run(t *testing.T) {
+	yml := `
+default: foo
+tasks:
+  - name: foo
+    actions:
+      - echo $ORK_TEST_RUN_TASK_ENV
+`
+	events := []Event{}
+	f := New().
+		WithEnv(Env{"ORK_TEST_RUN_TASK_ENV": "applied"}).
+		WithSubscriber(SubscriberFunc(func(e Event) { events = append(events, e) }))
+	require.NoError(t, f.Parse([]byte(yml)))
+	defer os.Unsetenv("ORK_TEST_RUN_TASK_ENV")
+
+	for _, run := range []func(Logger) error{
+		func(log Logger) error { return f.RunTask(context.Background(), "foo", log) },
+		func(log Logger) error { return f.RunDefault(context.Background(), log) },
+	} {
+		events = events[:0]
+		log := NewMockLogger()
+		require.NoError(t, run(log))
+		assert.Equal(t, []string{"applied\n"}, log.Outputs())
+		require.NotEmpty(t, events)
+		assert.IsType(t, RunStarted{}, events[0])
+		assert.IsType(t, RunFinished{}, events[len(events)-1])
+	}
+}
@@ -0,0 +1,203 @@
+package ork
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"sync"
+)
+
+// the ways in which the output of the tasks' actions can be presented
+const (
+	OUTPUT_PLAIN    = "plain"    // as is (default)
+	OUTPUT_PREFIXED = "prefixed" // every line is prefixed with the task label (colored in terminals)
+	OUTPUT_GROUPED  = "grouped"  // buffered and printed as a block when the task finishes
+	OUTPUT_GITHUB   = "github"   // surrounded by github actions' group commands
+)
+
+var (
+	outputModes = []string{OUTPUT_PLAIN, OUTPUT_PREFIXED, OUTPUT_GROUPED, OUTPUT_GITHUB}
+	labelColors = []int{31, 32, 33, 34, 35, 36, 91, 92, 93, 94, 95, 96}
+)
+
+func ValidateOutputMode(mode string) error {
+	for _, m := range outputModes {
+		if m == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output mode: %s (one of %s)", mode, strings.Join(outputModes, ", "))
+}
+
+// a logger that presents the output of a single task's actions
+// according to the output mode
+type taskOutput struct {
+	Logger
+	events *EventBus
+	label  string
+	mode   string
+
+	mu      sync.Mutex
+	stdout  bytes.Buffer           // pending (incomplete or grouped) output
+	stderr  bytes.Buffer           // pending (incomplete or grouped) error output
+	started bool                   // the github group has been opened
+	newline bool                   // the last stdout output of the github group ended with a newline
+	flushed bool                   // the task has finished
+	file    io.WriteCloser         // all the output is also recorded here (if set)
+	lines   map[string]*lineBuffer // the output lines per stream (for the events)
+}
+
+func newTaskOutput(logger Logger, events *EventBus, label string, mode string) *taskOutput {
+	lines := map[string]*lineBuffer{STREAM_STDOUT: {}, STREAM_STDERR: {}}
+	return &taskOutput{Logger: logger, events: events, label: label, mode: mode, lines: lines}
+}
+
+// record all the output in the file as well
+// the file is closed when the output is flushed
+func (o *taskOutput) WithLogFile(file io.WriteCloser) *taskOutput {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.file = file
+	return o
+}
+
+func (o *taskOutput) Write(p []byte) (n int, err error) {
+	o.Output(string(p))
+	return len(p), nil
+}
+
+func (o *taskOutput) Output(message string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.write(message, &o.stdout, o.Logger.Output)
+}
+
+func (o *taskOutput) OutputStderr(message string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.write(message, &o.stderr, o.Logger.OutputStderr)
+}
+
+func (o *taskOutput) write(message string, pending *bytes.Buffer, output func(string)) {
+	// the output is always published as events (along with the task)
+	// but is only presented as such by structured loggers
+	stream := STREAM_STDOUT
+	if pending == &o.stderr {
+		stream = STREAM_STDERR
+	}
+	o.events.publishOutput(o.label, stream, o.lines[stream].lines(message))
+	if o.file != nil {
+		o.file.Write([]byte(message))
+	}
+
+	switch {
+	case isStructured(o.Logger):
+		return
+	case o.mode == OUTPUT_PREFIXED:
+		pending.WriteString(message)
+		for {
+			line, err := pending.ReadString('\n')
+			if err != nil {
+				// keep the incomplete line for later
+				pending.Reset()
+				pending.WriteString(line)
+				return
+			}
+			output(o.prefix(stream) + line)
+		}
+	case o.mode == OUTPUT_GROUPED && !o.flushed:
+		pending.WriteString(message)
+	case o.mode == OUTPUT_GITHUB && !o.flushed:
+		if !o.started {
+			o.started = true
+			o.Logger.Output(fmt.Sprintf("::group::%s\n", o.label))
+		}
+		if pending == &o.stdout && message != "" {
+			o.newline = strings.HasSuffix(message, "\n")
+		}
+		output(message)
+	default:
+		output(message)
+	}
+}
+
+// publish the incomplete output lines of the finished action
+func (o *taskOutput) EndAction() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.endLines()
+}
+
+func (o *taskOutput) endLines() {
+	for _, stream := range []string{STREAM_STDOUT, STREAM_STDERR} {
+		o.events.publishOutput(o.label, stream, o.lines[stream].flush())
+	}
+}
+
+// output any pending messages
+// any output produced after the task has finished (e.g. from background actions)
+// will be printed as is
+func (o *taskOutput) Flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.endLines()
+	if o.flushed {
+		return
+	}
+	o.flushed = true
+	if o.file != nil {
+		o.file.Close()
+		o.file = nil
+	}
+	switch o.mode {
+	case OUTPUT_PREFIXED:
+		if o.stdout.Len() > 0 {
+			o.Logger.Output(o.prefix(STREAM_STDOUT) + terminated(o.stdout.String()))
+		}
+		if o.stderr.Len() > 0 {
+			o.Logger.OutputStderr(o.prefix(STREAM_STDERR) + terminated(o.stderr.String()))
+		}
+	case OUTPUT_GROUPED:
+		if o.stdout.Len() > 0 || o.stderr.Len() > 0 {
+			o.Logger.Output(fmt.Sprintf("==> [%s]\n", o.label))
+		}
+		if o.stdout.Len() > 0 {
+			o.Logger.Output(terminated(o.stdout.String()))
+		}
+		if o.stderr.Len() > 0 {
+			o.Logger.OutputStderr(terminated(o.stderr.String()))
+		}
+	case OUTPUT_GITHUB:
+		if o.started {
+			// the group command needs to be on a line of its own
+			if !o.newline {
+				o.Logger.Output("\n")
+			}
+			o.Logger.Output("::endgroup::\n")
+		}
+	}
+	o.stdout.Reset()
+	o.stderr.Reset()
+}
+
+// make sure that the message ends with a newline
+func terminated(message string) string {
+	if strings.HasSuffix(message, "\n") {
+		return message
+	}
+	return message + "\n"
+}
+
+// the task's label in a color that is unique per label
+// the label is not colored unless the stream is written to a terminal
+func (o *taskOutput) prefix(stream string) string {
+	if !isTerminalOutput(o.Logger, stream) {
+		return fmt.Sprintf("[%s] ", o.label)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(o.label))
+	color := labelColors[h.Sum32()%uint32(len(labelColors))]
+	return fmt.Sprintf("\033[%dm[%s]\033[0m ", color, o.label)
+}
@@ -0,0 +1,81 @@
+package ork
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var outputYml = `
+tasks:
+  - name: a
+    actions:
+      - bash -c "echo a1; echo a2 >&2; printf a3"
+  - name: b
+    depends_on:
+      - a
+    actions:
+      - echo b1
+    on_success:
+      - echo b2
+`
+
+func Test_Output_Modes(t *testing.T) {
+	prefixA := "[a] "
+	prefixB := "[b] "
+
+	kases := []struct {
+		mode    string
+		outputs string
+		stderr  string
+	}{
+		{OUTPUT_PLAIN, "a1\na3b1\nb2\n", "a2\n"},
+		{
+			OUTPUT_PREFIXED,
+			prefixA + "a1\n" + prefixA + "a3\n" + prefixB + "b1\n" + prefixB + "b2\n",
+			prefixA + "a2\n",
+		},
+		{
+			OUTPUT_GROUPED,
+			"==> [a]\na1\na3\n==> [b]\nb1\nb2\n",
+			"a2\n",
+		},
+		{
+			OUTPUT_GITHUB,
+			"::group::a\na1\na3\n::endgroup::\n::group::b\nb1\nb2\n::endgroup::\n",
+			"a2\n",
+		},
+	}
+	for _, kase := range kases {
+		f := New().WithOutputMode(kase.mode)
+		require.NoError(t, f.Parse([]byte(outputYml)), kase.mode)
+		log := NewMockLogger()
+		require.NoError(t, f.RunTask(context.Background(), "b", log), kase.mode)
+		assert.Equal(t, kase.outputs, strings.Join(log.Outputs(), ""), kase.mode)
+		assert.Equal(t, kase.stderr, strings.Join(log.Stderr(), ""), kase.mode)
+	}
+}
+
+// a logger whose stdout is a terminal
+type terminalLogger struct{ *MockLogger }
+
+func (l terminalLogger) Terminal(stream string) bool { return stream == STREAM_STDOUT }
+
+func Test_Output_Prefix_Is_Colored_Per_Task(t *testing.T) {
+	log := terminalLogger{NewMockLogger()}
+	prefix := newTaskOutput(log, nil, "foo", OUTPUT_PREFIXED).prefix(STREAM_STDOUT)
+	assert.Regexp(t, `^\033\[\d+m\[foo\]\033\[0m $`, prefix)
+	assert.Equal(t, prefix, newTaskOutput(log, nil, "foo", OUTPUT_PREFIXED).prefix(STREAM_STDOUT))
+	// the label is not colored in the output that is not written to a terminal
+	assert.Equal(t, "[foo] ", newTaskOutput(log, nil, "foo", OUTPUT_PREFIXED).prefix(STREAM_STDERR))
+}
+
+func Test_ValidateOutputMode(t *testing.T) {
+	for _, mode := range outputModes {
+		assert.NoError(t, ValidateOutputMode(mode))
+	}
+	assert.ErrorContains(t, ValidateOutputMode("foo"), "unknown output mode: foo")
+}
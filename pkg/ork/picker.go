@@ -0,0 +1,268 @@
+package ork
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const DEFAULT_PICKER_HEIGHT = 10 // the maximum number of visible items
+
+// returned when the user exits the picker without choosing anything
+var ErrPickerCancelled = errors.New("no task was picked")
+
+type PickerItem struct {
+	Label       string
+	Description string
+}
+
+// the actionable tasks of the orkfile as picker items (in alphabetical order)
+func PickerItems(f *Orkfile) []PickerItem {
+	items := []PickerItem{}
+	for _, label := range AllLabels(f) {
+		items = append(items, PickerItem{Label: label, Description: f.inventory.Find(label).Description})
+	}
+	return items
+}
+
+// an interactive list of items that is narrowed down by fuzzy-searching as the user types
+// keys: up/down (or C-p/C-n) move, tab toggles the selection of the current item,
+// enter picks the selected items (or the current one), esc or C-c cancels
+type Picker struct {
+	in       io.Reader
+	out      io.Writer
+	height   int
+	items    []PickerItem
+	query    []rune
+	matches  []PickerItem
+	cursor   int
+	selected []string // in the order of selection
+	rendered int      // the number of lines of the last frame
+}
+
+func NewPicker(items []PickerItem) *Picker {
+	p := &Picker{in: os.Stdin, out: os.Stderr, height: DEFAULT_PICKER_HEIGHT, items: items}
+	p.filter()
+	return p
+}
+
+// the keystrokes are read from the input (one key per read, as delivered by a terminal in raw mode)
+func (p *Picker) WithInput(in io.Reader) *Picker {
+	p.in = in
+	return p
+}
+
+func (p *Picker) WithOutput(out io.Writer) *Picker {
+	p.out = out
+	return p
+}
+
+// the labels of the picked items
+func (p *Picker) Run() ([]string, error) {
+	if f, ok := p.in.(*os.File); ok && IsTerminal(f) {
+		restore, err := rawTerminal(f)
+		if err != nil {
+			return nil, err
+		}
+		defer restore()
+	}
+	defer p.clear()
+
+	buf := make([]byte, 32)
+	for {
+		p.render()
+		n, err := p.in.Read(buf)
+		if n == 0 && err != nil {
+			if err == io.EOF {
+				return nil, ErrPickerCancelled
+			}
+			return nil, err
+		}
+		key := string(buf[:n])
+		switch key {
+		case "\r", "\n":
+			if len(p.selected) > 0 {
+				return p.selected, nil
+			}
+			if len(p.matches) > 0 {
+				return []string{p.matches[p.cursor].Label}, nil
+			}
+		case "\x1b", "\x03", "\x04":
+			return nil, ErrPickerCancelled
+		case "\x1b[A", "\x1bOA", "\x10":
+			p.move(-1)
+		case "\x1b[B", "\x1bOB", "\x0e":
+			p.move(1)
+		case "\t":
+			p.toggle()
+			p.move(1)
+		case "\x7f", "\b":
+			if len(p.query) > 0 {
+				p.query = p.query[:len(p.query)-1]
+				p.filter()
+			}
+		case "\x15":
+			p.query = nil
+			p.filter()
+		default:
+			if !strings.HasPrefix(key, "\x1b") {
+				for _, r := range key {
+					if unicode.IsPrint(r) {
+						p.query = append(p.query, r)
+					}
+				}
+				p.filter()
+			}
+		}
+	}
+}
+
+func (p *Picker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.cursor = (p.cursor + delta + len(p.matches)) % len(p.matches)
+}
+
+func (p *Picker) toggle() {
+	if len(p.matches) == 0 {
+		return
+	}
+	label := p.matches[p.cursor].Label
+	for i, s := range p.selected {
+		if s == label {
+			p.selected = append(p.selected[:i], p.selected[i+1:]...)
+			return
+		}
+	}
+	p.selected = append(p.selected, label)
+}
+
+func (p *Picker) isSelected(label string) bool {
+	for _, s := range p.selected {
+		if s == label {
+			return true
+		}
+	}
+	return false
+}
+
+// narrow down the items to the ones that match the query (best matches first)
+func (p *Picker) filter() {
+	type match struct {
+		item  PickerItem
+		score int
+	}
+	matches := []match{}
+	for _, item := range p.items {
+		if score, ok := fuzzyScore(string(p.query), item.Label); ok {
+			matches = append(matches, match{item, score})
+		} else if len(p.query) > 0 && strings.Contains(strings.ToLower(item.Description), strings.ToLower(string(p.query))) {
+			// the description is only searched for the exact query (ranked after the labels)
+			matches = append(matches, match{item, -1 << 20})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	p.matches = []PickerItem{}
+	for _, m := range matches {
+		p.matches = append(p.matches, m.item)
+	}
+	p.cursor = 0
+}
+
+// whether all the characters of the query appear in the candidate in the same order (ignoring case)
+// consecutive characters and characters at the start of words score higher, gaps score lower
+func fuzzyScore(query string, candidate string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	q, c := []rune(strings.ToLower(query)), []rune(strings.ToLower(candidate))
+	score, qi, last := 0, 0, -1
+	for ci := 0; ci < len(c) && qi < len(q); ci++ {
+		if c[ci] != q[qi] {
+			continue
+		}
+		switch {
+		case ci == 0 || strings.ContainsRune(".-_/: ", c[ci-1]):
+			score += 8
+		case ci == last+1:
+			score += 5
+		}
+		if last >= 0 {
+			score -= ci - last - 1
+		}
+		last = ci
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	// prefer shorter candidates among equal matches
+	return score*100 - len(c), true
+}
+
+// draw the current frame over the previous one
+func (p *Picker) render() {
+	p.clear()
+	lines := []string{fmt.Sprintf("> %s", string(p.query)), fmt.Sprintf("  %d/%d", len(p.matches), len(p.items))}
+	// keep the cursor visible
+	start := 0
+	if p.cursor >= p.height {
+		start = p.cursor - p.height + 1
+	}
+	for i := start; i < len(p.matches) && i < start+p.height; i++ {
+		item := p.matches[i]
+		pointer, mark := " ", " "
+		if i == p.cursor {
+			pointer = ">"
+		}
+		if p.isSelected(item.Label) {
+			mark = "*"
+		}
+		line := fmt.Sprintf("%s%s %s", pointer, mark, item.Label)
+		if item.Description != "" {
+			line += "  " + item.Description
+		}
+		lines = append(lines, line)
+	}
+	fmt.Fprint(p.out, strings.Join(lines, "\r\n"))
+	p.rendered = len(lines)
+}
+
+// erase the last frame
+func (p *Picker) clear() {
+	if p.rendered == 0 {
+		return
+	}
+	if p.rendered > 1 {
+		fmt.Fprintf(p.out, "\x1b[%dA", p.rendered-1)
+	}
+	fmt.Fprint(p.out, "\r\x1b[J")
+	p.rendered = 0
+}
+
+// put the terminal in raw mode (using stty, which is not available on windows)
+// and return a function that restores its previous state
+func rawTerminal(f *os.File) (func(), error) {
+	state := exec.Command("stty", "-g")
+	state.Stdin = f
+	saved, err := state.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal state: %v", err)
+	}
+	raw := exec.Command("stty", "raw", "-echo")
+	raw.Stdin = f
+	if err := raw.Run(); err != nil {
+		return nil, fmt.Errorf("failed to set terminal to raw mode: %v", err)
+	}
+	return func() {
+		restore := exec.Command("stty", strings.TrimSpace(string(saved)))
+		restore.Stdin = f
+		restore.Run()
+	}, nil
+}
@@ -0,0 +1,77 @@
+package ork
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// delivers one key per read (like a terminal in raw mode)
+type keys []string
+
+func (k *keys) Read(p []byte) (int, error) {
+	if len(*k) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*k)[0])
+	*k = (*k)[1:]
+	return n, nil
+}
+
+func Test_FuzzyScore(t *testing.T) {
+	_, ok := fuzzyScore("bld", "build")
+	assert.True(t, ok)
+	_, ok = fuzzyScore("dlb", "build")
+	assert.False(t, ok)
+	_, ok = fuzzyScore("", "build")
+	assert.True(t, ok)
+
+	// word starts and consecutive characters rank higher than scattered matches
+	start, _ := fuzzyScore("dp", "docker.push")
+	scattered, _ := fuzzyScore("dp", "deploy")
+	assert.Greater(t, start, scattered)
+	consecutive, _ := fuzzyScore("test", "test.unit")
+	gaps, _ := fuzzyScore("test", "the.east")
+	assert.Greater(t, consecutive, gaps)
+}
+
+func Test_Picker_Run(t *testing.T) {
+	items := []PickerItem{
+		{Label: "build", Description: "compile the binary"},
+		{Label: "deploy", Description: "ship it"},
+		{Label: "docker.push"},
+		{Label: "test"},
+	}
+	kases := []struct {
+		description string
+		keys        keys
+		expected    []string
+		err         error
+	}{
+		{"current item", keys{"\x1b[B", "\r"}, []string{"deploy"}, nil},
+		{"fuzzy search", keys{"d", "p", "\r"}, []string{"docker.push"}, nil},
+		{"search descriptions", keys{"s", "h", "i", "p", "\r"}, []string{"deploy"}, nil},
+		{"backspace", keys{"x", "\x7f", "t", "\r"}, []string{"test"}, nil},
+		{"wrap around", keys{"\x1b[A", "\r"}, []string{"test"}, nil},
+		{"multiple selections", keys{"t", "\t", "\x15", "\t", "\t", "\n"}, []string{"test", "build", "deploy"}, nil},
+		{"unselect", keys{"\t", "\x1b[A", "\t", "\r"}, []string{"deploy"}, nil},
+		{"no matches", keys{"z", "\r", "\x1b"}, nil, ErrPickerCancelled},
+		{"cancel", keys{"\x03"}, nil, ErrPickerCancelled},
+		{"end of input", keys{"b"}, nil, ErrPickerCancelled},
+	}
+	for _, kase := range kases {
+		kase := kase
+		out := bytes.NewBuffer([]byte{})
+		labels, err := NewPicker(items).WithInput(&kase.keys).WithOutput(out).Run()
+		if kase.err != nil {
+			assert.ErrorIs(t, err, kase.err, kase.description)
+			continue
+		}
+		require.NoError(t, err, kase.description)
+		assert.Equal(t, kase.expected, labels, kase.description)
+		assert.Contains(t, out.String(), "build  compile the binary", kase.description)
+	}
+}
@@ -0,0 +1,83 @@
+//go:build !windows
+// +build !windows
+
+package ork
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// start the process in its own process group (if requested)
+// so that ork is in control of the delivery of signals to the process and its children
+func setupProcess(cmd *exec.Cmd, ownGroup bool) {
+	if ownGroup {
+		// the runner may have already set some of the attributes (e.g. chroot)
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.Setpgid = true
+	}
+}
+
+// send the signal to the process or to its entire process group
+func signalProcess(cmd *exec.Cmd, sig os.Signal, group bool) error {
+	if !group {
+		return cmd.Process.Signal(sig)
+	}
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		s = syscall.SIGINT
+	}
+	return syscall.Kill(-cmd.Process.Pid, s)
+}
+
+// kill the process and any of its remaining children
+func killProcess(cmd *exec.Cmd, group bool) error {
+	return signalProcess(cmd, syscall.SIGKILL, group)
+}
+
+// the descendants of the process (the children of a process that is not in
+// its own process group can not be reached through the group)
+func descendants(pid int) []int {
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=").Output()
+	if err != nil {
+		return nil
+	}
+	children := map[int][]int{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		child, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		parent, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		children[parent] = append(children[parent], child)
+	}
+	pids := []int{}
+	for queue := children[pid]; len(queue) > 0; queue = queue[1:] {
+		pids = append(pids, queue[0])
+		queue = append(queue, children[queue[0]]...)
+	}
+	return pids
+}
+
+// send the signal to every one of the processes
+func signalPids(pids []int, sig os.Signal) {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		s = syscall.SIGINT
+	}
+	for _, pid := range pids {
+		syscall.Kill(pid, s)
+	}
+}
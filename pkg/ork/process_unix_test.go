@@ -0,0 +1,87 @@
+//go:build !windows
+// +build !windows
+
+package ork
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Action_Cancellation_Kills_Orphaned_Children(t *testing.T) {
+	pidfile := filepath.Join(t.TempDir(), "orphan.pid")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	action := NewAction("bash -c \"sleep 30 & echo $! > " + pidfile + "; wait\"").
+		WithEnvExpansion(false).
+		WithStdin(strings.NewReader("")).
+		WithContext(ctx)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+	}()
+	var aerr *ActionError
+	require.ErrorAs(t, action.Execute(), &aerr)
+	assert.Equal(t, 128+int(syscall.SIGINT), aerr.ExitCode)
+
+	contents, err := os.ReadFile(pidfile)
+	require.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	require.NoError(t, err)
+	// the process might still be a zombie for a while
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(pid, 0) != nil || isZombie(pid)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_Action_Is_Killed_After_Grace_Period(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	action := NewAction("bash -c \"trap '' INT TERM; sleep 30\"").
+		WithStdin(strings.NewReader("")).
+		WithContext(ctx).
+		WithGracePeriod(100 * time.Millisecond)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	var aerr *ActionError
+	require.ErrorAs(t, action.Execute(), &aerr)
+	assert.Equal(t, 128+int(syscall.SIGKILL), aerr.ExitCode)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func Test_Action_Receives_The_Forwarded_Signal(t *testing.T) {
+	ctx, cancel := WithSignals(context.Background(), syscall.SIGUSR1)
+	defer cancel()
+	action := NewAction("sleep 30").WithStdin(strings.NewReader("")).WithContext(ctx)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	}()
+	var aerr *ActionError
+	require.ErrorAs(t, action.Execute(), &aerr)
+	assert.Equal(t, 128+int(syscall.SIGUSR1), aerr.ExitCode)
+	assert.Equal(t, syscall.SIGUSR1, SignalFrom(ctx))
+}
+
+func isZombie(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat))
+	return len(fields) > 2 && fields[2] == "Z"
+}
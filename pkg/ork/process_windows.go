@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package ork
+
+import (
+	"os"
+	"os/exec"
+)
+
+// process groups are not supported on windows
+func setupProcess(cmd *exec.Cmd, ownGroup bool) {}
+
+// windows does not support sending signals, so the process is killed
+func signalProcess(cmd *exec.Cmd, sig os.Signal, group bool) error {
+	return cmd.Process.Kill()
+}
+
+func killProcess(cmd *exec.Cmd, group bool) error {
+	return cmd.Process.Kill()
+}
+
+// the children of a process are not tracked on windows
+func descendants(pid int) []int { return nil }
+
+func signalPids(pids []int, sig os.Signal) {}
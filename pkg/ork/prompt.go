@@ -0,0 +1,252 @@
+package ork
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// the types of prompts
+const (
+	PROMPT_TEXT     = "text" // default
+	PROMPT_PASSWORD = "password"
+	PROMPT_SELECT   = "select"
+)
+
+// a value that is requested from the user before the task's actions are executed
+// the value is exported as an environment variable (named after the prompt)
+type Prompt struct {
+	Name    string   `yaml:"name"`
+	Message string   `yaml:"message"`
+	Type    string   `yaml:"type"`
+	Options []string `yaml:"options"` // the choices of a select prompt
+	Default string   `yaml:"default"`
+}
+
+// asks the user for the task's confirmation and prompts
+// values can also be supplied as parameters (e.g. in non-interactive mode)
+type prompter struct {
+	in          io.Reader
+	out         io.Writer
+	interactive bool
+	params      map[string]string
+	yes         bool // assume yes in confirmations (and the default values of prompts)
+}
+
+// prompts are interactive only when ork's standard input is a terminal
+func newPrompter(in io.Reader, params map[string]string, yes bool) *prompter {
+	if in == nil {
+		in = os.Stdin
+	}
+	return &prompter{in: in, out: os.Stderr, interactive: isTerminal(in), params: params, yes: yes}
+}
+
+func (p *prompter) Confirm(message string) error {
+	if p.yes {
+		return nil
+	}
+	if !p.interactive {
+		return fmt.Errorf("confirmation required: %s (use --yes in non-interactive mode)", message)
+	}
+	fmt.Fprintf(p.out, "%s [y/N] ", message)
+	answer, err := p.readLine()
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return nil
+	default:
+		return errors.New("not confirmed")
+	}
+}
+
+// the value of the prompt (either supplied as a parameter or entered by the user)
+func (p *prompter) Ask(prompt Prompt) (string, error) {
+	if prompt.Name == "" {
+		return "", &OrkfileError{errors.New("the name of the prompt has not been set")}
+	}
+	if value, ok := p.params[prompt.Name]; ok {
+		return value, prompt.validate(value)
+	}
+	if !p.interactive {
+		if p.yes && prompt.Default != "" {
+			return prompt.Default, nil
+		}
+		return "", fmt.Errorf("a value for %s is required (use --param %s=VALUE in non-interactive mode)", prompt.Name, prompt.Name)
+	}
+
+	message := prompt.Message
+	if message == "" {
+		message = prompt.Name
+	}
+	switch prompt.Type {
+	case "", PROMPT_TEXT:
+		return p.ask(message, prompt.Default)
+	case PROMPT_PASSWORD:
+		return p.askHidden(message)
+	case PROMPT_SELECT:
+		return p.choose(message, prompt)
+	default:
+		return "", &OrkfileError{fmt.Errorf("unknown prompt type: %s", prompt.Type)}
+	}
+}
+
+func (p *prompter) ask(message string, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", message, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", message)
+	}
+	value, err := p.readLine()
+	if value == "" {
+		value = def
+	}
+	return value, err
+}
+
+// the typed value is not echoed by the terminal
+// the value is not read at all if the terminal's echo can not be turned off
+func (p *prompter) askHidden(message string) (string, error) {
+	if f, ok := p.in.(*os.File); ok {
+		if err := setEcho(f, false); err != nil {
+			return "", fmt.Errorf("failed to hide the input of %s (use --param in this terminal): %v", message, err)
+		}
+		defer setEcho(f, true)
+	}
+	fmt.Fprintf(p.out, "%s: ", message)
+	value, err := p.readLine()
+	fmt.Fprintln(p.out)
+	return value, err
+}
+
+// the options are presented as a numbered list; the user can enter either a number or an option
+func (p *prompter) choose(message string, prompt Prompt) (string, error) {
+	if len(prompt.Options) == 0 {
+		return "", &OrkfileError{fmt.Errorf("prompt %s has no options", prompt.Name)}
+	}
+	for i, option := range prompt.Options {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, option)
+	}
+	for {
+		answer, err := p.ask(message, prompt.Default)
+		if err != nil {
+			return "", err
+		}
+		if i, err := strconv.Atoi(answer); err == nil && i > 0 && i <= len(prompt.Options) {
+			return prompt.Options[i-1], nil
+		}
+		if prompt.validate(answer) == nil {
+			return answer, nil
+		}
+		fmt.Fprintf(p.out, "invalid choice: %s\n", answer)
+	}
+}
+
+// read a single line from the input (byte by byte, so that nothing is consumed
+// beyond the line in case the input is shared with the task's actions)
+func (p *prompter) readLine() (string, error) {
+	var line strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := p.in.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line.WriteByte(buf[0])
+		}
+		if err == io.EOF && line.Len() > 0 {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+	}
+	return strings.TrimSpace(line.String()), nil
+}
+
+func (prompt Prompt) validate(value string) error {
+	if prompt.Type != PROMPT_SELECT {
+		return nil
+	}
+	for _, option := range prompt.Options {
+		if value == option {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value for %s: %s (one of %s)", prompt.Name, value, strings.Join(prompt.Options, ", "))
+}
+
+// turn the terminal's echo on or off (using stty, which is not available on windows)
+func setEcho(f *os.File, on bool) error {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = f
+	return cmd.Run()
+}
+
+// the values of password prompts are never logged
+var (
+	secretsMu sync.RWMutex
+	secrets   = map[string]bool{}
+)
+
+func addSecret(value string) {
+	if value == "" {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets[value] = true
+}
+
+// does the value contain any of the secret values?
+func containsSecret(value string) bool {
+	return redact(value) != value
+}
+
+// mask the values of the --param flags along with any secret values in the
+// command-line arguments, so that they can be recorded (e.g. in the history)
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	param := false // the previous argument was a --param flag
+	for i, arg := range args {
+		switch {
+		case param:
+			arg = redactParam(arg)
+		case strings.HasPrefix(arg, "--param="), strings.HasPrefix(arg, "-param="):
+			kv := strings.SplitN(arg, "=", 2)
+			arg = kv[0] + "=" + redactParam(kv[1])
+		}
+		param = arg == "--param" || arg == "-param"
+		redacted[i] = redact(arg)
+	}
+	return redacted
+}
+
+// KEY=VALUE => KEY=***
+func redactParam(param string) string {
+	kv := strings.SplitN(param, "=", 2)
+	if len(kv) != 2 {
+		return param
+	}
+	return kv[0] + "=***"
+}
+
+// replace all the secret values in the message
+func redact(msg string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for secret := range secrets {
+		msg = strings.ReplaceAll(msg, secret, "***")
+	}
+	return msg
+}
@@ -0,0 +1,143 @@
+package ork
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/apsdehal/go-logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func interactivePrompter(input string, params map[string]string) (*prompter, *bytes.Buffer) {
+	out := bytes.NewBuffer([]byte{})
+	return &prompter{in: strings.NewReader(input), out: out, interactive: true, params: params}, out
+}
+
+func Test_Prompter_Confirm(t *testing.T) {
+	kases := []struct {
+		input     string
+		confirmed bool
+	}{
+		{"y\n", true},
+		{"Yes\n", true},
+		{"n\n", false},
+		{"\n", false},
+	}
+	for _, kase := range kases {
+		p, out := interactivePrompter(kase.input, nil)
+		err := p.Confirm("Deploy?")
+		assert.Equal(t, kase.confirmed, err == nil, kase.input)
+		assert.Equal(t, "Deploy? [y/N] ", out.String())
+	}
+
+	p, _ := interactivePrompter("", nil)
+	assert.ErrorContains(t, p.Confirm("Deploy?"), "failed to read input")
+}
+
+func Test_Prompter_Ask(t *testing.T) {
+	p, out := interactivePrompter("\n1.2.0\nsecret\n3\nstaging\n", map[string]string{"REGION": "eu"})
+
+	value, err := p.Ask(Prompt{Name: "CHANNEL", Default: "stable"})
+	require.NoError(t, err)
+	assert.Equal(t, "stable", value)
+
+	value, err = p.Ask(Prompt{Name: "VERSION", Message: "Version"})
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", value)
+
+	value, err = p.Ask(Prompt{Name: "TOKEN", Type: PROMPT_PASSWORD})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	// invalid choices are rejected until a valid one is entered
+	value, err = p.Ask(Prompt{Name: "STAGE", Type: PROMPT_SELECT, Options: []string{"staging", "production"}})
+	require.NoError(t, err)
+	assert.Equal(t, "staging", value)
+
+	// parameters take precedence over the user's input
+	value, err = p.Ask(Prompt{Name: "REGION"})
+	require.NoError(t, err)
+	assert.Equal(t, "eu", value)
+
+	assert.Equal(t, "CHANNEL [stable]: Version: TOKEN: \n  1) staging\n  2) production\nSTAGE: invalid choice: 3\nSTAGE: ", out.String())
+	assert.NotContains(t, out.String(), "secret")
+}
+
+func Test_Task_Prompts_In_NonInteractive_Mode(t *testing.T) {
+	yml := `
+tasks:
+  - name: deploy
+    confirm: Deploy to production?
+    prompt:
+      - name: ORK_TEST_STAGE
+        type: select
+        options: [staging, production]
+        default: staging
+      - name: ORK_TEST_TOKEN
+        type: password
+    actions:
+      - echo $ORK_TEST_STAGE $ORK_TEST_TOKEN
+`
+	run := func(f *Orkfile) (*MockLogger, error) {
+		require.NoError(t, f.Parse([]byte(yml)))
+		log := NewMockLogger()
+		require.NoError(t, log.SetLogLevel(LOG_LEVEL_DEBUG))
+		return log, f.RunTask(context.Background(), "deploy", log)
+	}
+	stdin := func() *Orkfile { return New().WithStdin(bytes.NewReader(nil)) }
+
+	_, err := run(stdin())
+	assert.ErrorContains(t, err, "confirmation required: Deploy to production?")
+
+	_, err = run(stdin().WithYes(true))
+	assert.ErrorContains(t, err, "a value for ORK_TEST_TOKEN is required")
+
+	_, err = run(stdin().WithYes(true).WithParams(map[string]string{"ORK_TEST_STAGE": "dev", "ORK_TEST_TOKEN": "s3cr3t"}))
+	assert.ErrorContains(t, err, "invalid value for ORK_TEST_STAGE: dev")
+
+	log, err := run(stdin().WithYes(true).WithParams(map[string]string{"ORK_TEST_TOKEN": "s3cr3t"}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"staging s3cr3t\n"}, log.Outputs())
+	assert.Contains(t, log.Logs(logger.DebugLevel), "[deploy] expanded: echo staging ***")
+	for _, msg := range log.Logs(logger.DebugLevel) {
+		assert.NotContains(t, msg, "s3cr3t")
+	}
+	os.Unsetenv("ORK_TEST_STAGE")
+	os.Unsetenv("ORK_TEST_TOKEN")
+}
+
+func Test_RedactArgs(t *testing.T) {
+	args := []string{"-l", "debug", "--param", "TOKEN=s3cr3t", "--param=A=b", "-param", "C", "ci"}
+	assert.Equal(t,
+		[]string{"-l", "debug", "--param", "TOKEN=***", "--param=A=***", "-param", "C", "ci"},
+		redactArgs(args))
+}
+
+func Test_Prompter_Is_Not_Interactive_Without_A_Terminal(t *testing.T) {
+	// /dev/null is a character device but not a terminal
+	in, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer in.Close()
+	p := newPrompter(in, nil, false)
+
+	assert.ErrorContains(t, p.Confirm("Deploy?"), "use --yes in non-interactive mode")
+	_, err = p.Ask(Prompt{Name: "TOKEN", Type: PROMPT_PASSWORD})
+	assert.ErrorContains(t, err, "use --param TOKEN=VALUE in non-interactive mode")
+}
+
+func Test_Prompter_Refuses_Visible_Passwords(t *testing.T) {
+	// the echo of /dev/null can not be turned off
+	in, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer in.Close()
+	out := bytes.NewBuffer([]byte{})
+	p := &prompter{in: in, out: out, interactive: true}
+
+	_, err = p.Ask(Prompt{Name: "TOKEN", Type: PROMPT_PASSWORD})
+	assert.ErrorContains(t, err, "failed to hide the input of TOKEN")
+	assert.Empty(t, out.String())
+}
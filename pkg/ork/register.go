@@ -0,0 +1,57 @@
+package ork
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var nonIdentifierChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// export the captured output of the action as environment variables
+// so that it is visible to all the subsequent actions, hooks and tasks
+func (a TaskAction) register(output string) error {
+	output = strings.TrimSuffix(output, "\n")
+	if a.Register != "" {
+		if err := os.Setenv(a.Register, output); err != nil {
+			return fmt.Errorf("failed to register %s: %v", a.Register, err)
+		}
+	}
+	if a.RegisterJSON != "" {
+		vars, err := flattenJSON(a.RegisterJSON, output)
+		if err != nil {
+			return fmt.Errorf("failed to register %s: %v", a.RegisterJSON, err)
+		}
+		for key, value := range vars {
+			if err := os.Setenv(key, value); err != nil {
+				return fmt.Errorf("failed to register %s: %v", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parse the JSON document and return the variables that will be exported:
+// the document itself under the supplied name and (if it is an object)
+// each one of its top-level fields under the name `<NAME>_<FIELD>`
+func flattenJSON(name string, document string) (map[string]string, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON output: %v", err)
+	}
+	vars := map[string]string{name: document}
+	if obj, ok := value.(map[string]interface{}); ok {
+		for field, v := range obj {
+			key := name + "_" + strings.ToUpper(nonIdentifierChars.ReplaceAllString(field, "_"))
+			if s, ok := v.(string); ok {
+				vars[key] = s
+			} else {
+				b, _ := json.Marshal(v)
+				vars[key] = string(b)
+			}
+		}
+	}
+	return vars, nil
+}
@@ -0,0 +1,98 @@
+package ork
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	RUNNER_LOCAL   = "local" // the actions are spawned as local processes (default)
+	DEFAULT_RUNNER = RUNNER_LOCAL
+)
+
+// the way in which the actions of a task are executed
+// a runner produces the command that will be spawned for an action (e.g. by
+// wrapping the statement in a container or a chroot); ork remains in control of
+// the command's IO streams, signals and exit status
+type Runner interface {
+	// the command that executes the (expanded) statement in the working directory
+	Command(statement string, dir string) (*exec.Cmd, error)
+}
+
+// creates the runner for the supplied task
+type RunnerFactory func(task *Task) (Runner, error)
+
+var (
+	runnersMu sync.RWMutex
+	runners   = map[string]RunnerFactory{
+		RUNNER_LOCAL: func(*Task) (Runner, error) { return LocalRunner{}, nil },
+	}
+)
+
+// make the runner available to tasks under the supplied name (`runner: name`)
+// an existing runner with the same name is replaced
+func RegisterRunner(name string, factory RunnerFactory) {
+	runnersMu.Lock()
+	defer runnersMu.Unlock()
+	runners[name] = factory
+}
+
+// the names of all the registered runners in alphabetical order
+func Runners() []string {
+	runnersMu.RLock()
+	defer runnersMu.RUnlock()
+	names := []string{}
+	for name := range runners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// the name of the task's runner (either explicitly set or implied by the task's attributes)
+func (t *Task) runnerName() (string, error) {
+	if t.Runner != "" {
+		return t.Runner, nil
+	}
+	switch {
+	case t.Container != nil && t.SSH != nil:
+		return "", &OrkfileError{errors.New("the runner is ambiguous (both container and ssh are set)")}
+	case t.Container != nil:
+		return RUNNER_CONTAINER, nil
+	case t.SSH != nil:
+		return RUNNER_SSH, nil
+	default:
+		return DEFAULT_RUNNER, nil
+	}
+}
+
+// create the runner of the task
+func (t *Task) runner() (Runner, error) {
+	name, err := t.runnerName()
+	if err != nil {
+		return nil, err
+	}
+	runnersMu.RLock()
+	factory, ok := runners[name]
+	runnersMu.RUnlock()
+	if !ok {
+		return nil, &OrkfileError{fmt.Errorf("unknown runner: %s (one of %s)", name, strings.Join(Runners(), ", "))}
+	}
+	return factory(t)
+}
+
+// spawns the actions as local processes
+type LocalRunner struct{}
+
+func (LocalRunner) Command(statement string, dir string) (*exec.Cmd, error) {
+	cmd, err := createCommand(statement)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Dir = dir
+	return cmd, nil
+}
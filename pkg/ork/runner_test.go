@@ -0,0 +1,88 @@
+package ork
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prefixes every statement with the task's name
+type prefixRunner struct {
+	prefix string
+}
+
+func (r prefixRunner) Command(statement string, dir string) (*exec.Cmd, error) {
+	return LocalRunner{}.Command("echo "+r.prefix+" "+statement, dir)
+}
+
+// register the runner for the duration of the test
+// (the previous runner with the same name, if any, is restored afterwards)
+func registerTestRunner(t *testing.T, name string, factory RunnerFactory) {
+	runnersMu.RLock()
+	previous, ok := runners[name]
+	runnersMu.RUnlock()
+	RegisterRunner(name, factory)
+	t.Cleanup(func() {
+		runnersMu.Lock()
+		defer runnersMu.Unlock()
+		if ok {
+			runners[name] = previous
+		} else {
+			delete(runners, name)
+		}
+	})
+}
+
+func Test_Task_Uses_Registered_Runner(t *testing.T) {
+	registerTestRunner(t, "prefix", func(task *Task) (Runner, error) { return prefixRunner{prefix: task.Name}, nil })
+	assert.Contains(t, Runners(), "prefix")
+	assert.Contains(t, Runners(), RUNNER_LOCAL)
+
+	yml := `
+tasks:
+  - name: remote
+    runner: prefix
+    actions:
+      - hello
+  - name: local
+    actions:
+      - echo hello
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+
+	logger := NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "remote", logger))
+	assert.Equal(t, []string{"remote hello\n"}, logger.Outputs())
+
+	logger = NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "local", logger))
+	assert.Equal(t, []string{"hello\n"}, logger.Outputs())
+
+	// the registry is restored after the test
+	t.Run("cleanup", func(t *testing.T) {
+		registerTestRunner(t, "prefix-cleanup", func(*Task) (Runner, error) { return LocalRunner{}, nil })
+	})
+	assert.NotContains(t, Runners(), "prefix-cleanup")
+}
+
+func Test_Task_With_Unknown_Runner_Fails(t *testing.T) {
+	yml := `
+tasks:
+  - name: build
+    runner: nonexistent
+    actions:
+      - echo hello
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+
+	logger := NewMockLogger()
+	err := f.RunTask(context.Background(), "build", logger)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown runner: nonexistent")
+	assert.Empty(t, logger.Outputs())
+}
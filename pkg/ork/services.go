@@ -0,0 +1,206 @@
+package ork
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	DEFAULT_READINESS_TIMEOUT  = 30 * time.Second
+	DEFAULT_READINESS_INTERVAL = 250 * time.Millisecond
+)
+
+// the conditions under which a background action is considered to be ready
+// all the specified conditions need to be satisfied
+type Readiness struct {
+	TCP      string        `yaml:"tcp"`     // an address that accepts connections
+	HTTP     string        `yaml:"http"`    // a URL that responds with 200
+	Log      string        `yaml:"log"`     // a regex that matches a line in the action's output
+	Command  string        `yaml:"command"` // an action that succeeds
+	Timeout  time.Duration `yaml:"timeout"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// a long-running action that was started in the background
+type Service struct {
+	label   string
+	index   int // the position of the background action in the task's actions
+	process *Process
+}
+
+// the services started during the execution of a task
+// they are stopped (in reverse order) when the task has finished
+type Services []*Service
+
+func (s *Services) Add(svc *Service) {
+	*s = append(*s, svc)
+}
+
+// has the task's background action already been started?
+func (s Services) started(label string, index int) bool {
+	for _, svc := range s {
+		if svc.label == label && svc.index == index {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Services) Stop(logger Logger) {
+	for i := len(s) - 1; i >= 0; i-- {
+		svc := s[i]
+		logger.Debugf("[%s] stopping service: %s", svc.label, svc.process.statement)
+		if err := svc.process.Stop(syscall.SIGTERM); err != nil {
+			logger.Debugf("[%s] service %s exited: %v", svc.label, svc.process.statement, err)
+		}
+	}
+}
+
+// creates the action of a readiness command (in the same way as the task's actions)
+type probeFactory func(statement string) (*Action, error)
+
+// start the action in the background and wait for it to become ready
+func startService(ctx context.Context, label string, action *Action, ready *Readiness, probe probeFactory, logger Logger) (*Service, error) {
+	var matcher *lineMatcher
+	if ready != nil && ready.Log != "" {
+		re, err := regexp.Compile(ready.Log)
+		if err != nil {
+			return nil, fmt.Errorf("invalid readiness log pattern %s: %v", ready.Log, err)
+		}
+		matcher = newLineMatcher(action.stdout, re)
+		action.WithStdout(matcher)
+	}
+
+	process, err := action.Start()
+	if err != nil {
+		return nil, err
+	}
+	svc := &Service{label: label, process: process}
+	if ready == nil {
+		return svc, nil
+	}
+
+	if err := ready.wait(ctx, process, matcher, probe); err != nil {
+		process.Stop(syscall.SIGTERM)
+		return nil, err
+	}
+	return svc, nil
+}
+
+// poll the readiness conditions until all of them are satisfied, the timeout expires
+// or the process exits
+func (r *Readiness) wait(ctx context.Context, process *Process, matcher *lineMatcher, probe probeFactory) error {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = DEFAULT_READINESS_TIMEOUT
+	}
+	interval := r.Interval
+	if interval == 0 {
+		interval = DEFAULT_READINESS_INTERVAL
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if ready, err := r.isReady(matcher, probe); err != nil || ready {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ErrInterrupted
+		case <-process.Exited():
+			return errors.New("service exited before becoming ready")
+		case <-deadline.C:
+			return fmt.Errorf("service did not become ready within %v", timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// an error is returned if the readiness command can not be created
+func (r *Readiness) isReady(matcher *lineMatcher, probe probeFactory) (bool, error) {
+	if r.TCP != "" {
+		conn, err := net.DialTimeout("tcp", r.TCP, time.Second)
+		if err != nil {
+			return false, nil
+		}
+		conn.Close()
+	}
+	if r.HTTP != "" {
+		client := http.Client{Timeout: time.Second}
+		resp, err := client.Get(r.HTTP)
+		if err != nil {
+			return false, nil
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return false, nil
+		}
+	}
+	if matcher != nil && !matcher.Matched() {
+		return false, nil
+	}
+	if r.Command != "" {
+		action, err := probe(r.Command)
+		if err != nil {
+			return false, err
+		}
+		if err := action.Execute(); err != nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// a writer that passes its input through and records whether
+// any of the lines matched the pattern
+type lineMatcher struct {
+	mu      sync.Mutex
+	out     io.Writer
+	re      *regexp.Regexp
+	pending []byte
+	matched bool
+}
+
+func newLineMatcher(out io.Writer, re *regexp.Regexp) *lineMatcher {
+	return &lineMatcher{out: out, re: re}
+}
+
+func (m *lineMatcher) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	if !m.matched {
+		m.pending = append(m.pending, p...)
+		for {
+			idx := bytes.IndexByte(m.pending, '\n')
+			if idx < 0 {
+				break
+			}
+			if m.re.Match(m.pending[:idx]) {
+				m.matched = true
+			}
+			m.pending = m.pending[idx+1:]
+		}
+		if m.matched {
+			m.pending = nil
+		}
+	}
+	m.mu.Unlock()
+	return m.out.Write(p)
+}
+
+func (m *lineMatcher) Matched() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.matched
+}
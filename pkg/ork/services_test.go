@@ -0,0 +1,188 @@
+package ork
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Background_Action_Is_Ready_On_Log_Line_And_Stopped_With_Task(t *testing.T) {
+	marker := "test_service_running"
+	defer os.Remove(marker)
+
+	yml := fmt.Sprintf(`
+tasks:
+  - name: db
+    expand_env: false
+    actions:
+      - run: bash -c "trap 'rm -f %s; exit 0' TERM; touch %s; sleep 0.2; echo accepting connections; while true; do sleep 0.05; done"
+        background: true
+        ready:
+          log: accepting connections
+          timeout: 5s
+          interval: 10ms
+  - name: test
+    depends_on:
+      - db
+    actions:
+      - ls %s
+`, marker, marker, marker)
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+
+	require.NoError(t, f.RunTask(context.Background(), "test", log))
+	outputs := strings.Join(log.Outputs(), "")
+	assert.Contains(t, outputs, "accepting connections\n")
+	assert.Contains(t, outputs, marker+"\n")
+	// the service has been stopped
+	_, err := os.Stat(marker)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_Background_Action_Readiness_Probes(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	kases := []struct {
+		description string
+		ready       string
+	}{
+		{"tcp", fmt.Sprintf("{tcp: %s}", listener.Addr())},
+		{"http", fmt.Sprintf("{http: %s}", server.URL)},
+		{"command", "{command: 'true'}"},
+		{"all of them", fmt.Sprintf("{tcp: %s, http: %s, command: 'true'}", listener.Addr(), server.URL)},
+	}
+	for _, kase := range kases {
+		yml := fmt.Sprintf(`
+tasks:
+  - name: svc
+    actions:
+      - run: sleep 30
+        background: true
+        ready: %s
+      - echo done
+`, kase.ready)
+		f := New().WithGracePeriod(time.Second)
+		require.NoError(t, f.Parse([]byte(yml)), kase.description)
+		log := NewMockLogger()
+		start := time.Now()
+		assert.NoError(t, f.RunTask(context.Background(), "svc", log), kase.description)
+		assert.Equal(t, []string{"done\n"}, log.Outputs(), kase.description)
+		assert.Less(t, time.Since(start), 5*time.Second, kase.description)
+	}
+}
+
+func Test_Background_Action_Readiness_Failures(t *testing.T) {
+	kases := []struct {
+		description string
+		action      string
+		errmsg      string
+	}{
+		{
+			"service exits",
+			`{run: "bash -c \"exit 1\"", background: true, ready: {command: "false", interval: 10ms}}`,
+			"service exited before becoming ready",
+		},
+		{
+			"timeout",
+			`{run: "sleep 30", background: true, ready: {command: "false", timeout: 100ms, interval: 10ms}}`,
+			"service did not become ready within 100ms",
+		},
+		{
+			"invalid log pattern",
+			`{run: "sleep 30", background: true, ready: {log: "a(b"}}`,
+			"invalid readiness log pattern",
+		},
+	}
+	for _, kase := range kases {
+		yml := fmt.Sprintf(`
+tasks:
+  - name: svc
+    actions:
+      - %s
+      - echo unreachable
+    on_failure:
+      - echo failure
+`, kase.action)
+		f := New()
+		require.NoError(t, f.Parse([]byte(yml)), kase.description)
+		log := NewMockLogger()
+		assert.ErrorContains(t, f.RunTask(context.Background(), "svc", log), kase.errmsg, kase.description)
+		assert.Equal(t, []string{"failure\n"}, log.Outputs(), kase.description)
+	}
+}
+
+func Test_Background_Action_Readiness_Command_Is_Executed_Like_The_Actions(t *testing.T) {
+	dir := t.TempDir()
+	// the probe is executed in the task's working directory with the task's environment
+	// and its stderr is routed according to the task
+	yml := fmt.Sprintf(`
+tasks:
+  - name: svc
+    working_dir: %s
+    stderr: suppress
+    env:
+      - MARKER: service.ready
+    actions:
+      - run: bash -c "sleep 0.2; touch $MARKER; sleep 30"
+        background: true
+        ready: {command: "bash -c \"echo probing >&2; test -f $MARKER\"", timeout: 5s, interval: 10ms}
+      - echo done
+`, dir)
+	f := New().WithGracePeriod(time.Second)
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "svc", log))
+	assert.Equal(t, []string{"done\n"}, log.Outputs())
+	assert.Empty(t, log.Stderr())
+}
+
+func Test_Background_Action_Is_Started_Once_Per_Execution(t *testing.T) {
+	starts := filepath.Join(t.TempDir(), "starts")
+	yml := fmt.Sprintf(`
+tasks:
+  - name: db
+    expand_env: false
+    actions:
+      - run: bash -c "echo started >> %s; echo ready; sleep 30"
+        background: true
+        ready: {log: ready, timeout: 5s, interval: 10ms}
+    tasks:
+      - name: migrate
+        actions:
+          - echo migrate
+      - name: seed
+        actions:
+          - echo seed
+  - name: test
+    depends_on:
+      - db.migrate
+      - db.seed
+    actions:
+      - echo test
+`, starts)
+	f := New().WithGracePeriod(time.Second)
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "test", log))
+	assert.Equal(t, []string{"ready\n", "migrate\n", "seed\n", "test\n"}, log.Outputs())
+
+	// the parent is executed along with both of its children
+	contents, err := os.ReadFile(starts)
+	require.NoError(t, err)
+	assert.Equal(t, "started\n", string(contents))
+}
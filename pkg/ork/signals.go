@@ -0,0 +1,90 @@
+package ork
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+type signalKey struct{}
+
+// records the first signal received by ork
+type receivedSignal struct {
+	mu  sync.Mutex
+	sig os.Signal
+}
+
+// return a context that will be cancelled when ork receives one of the supplied signals
+// the received signal will be forwarded to the running action (see SignalFrom)
+func WithSignals(parent context.Context, sigs ...os.Signal) (context.Context, context.CancelFunc) {
+	received := &receivedSignal{}
+	ctx, cancel := context.WithCancel(context.WithValue(parent, signalKey{}, received))
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case sig := <-ch:
+			received.mu.Lock()
+			received.sig = sig
+			received.mu.Unlock()
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(ch)
+		cancel()
+	}
+}
+
+// return the signal that cancelled the context
+// the default is an interrupt (if the context was cancelled for any other reason)
+func SignalFrom(ctx context.Context) os.Signal {
+	if received, ok := ctx.Value(signalKey{}).(*receivedSignal); ok {
+		received.mu.Lock()
+		defer received.mu.Unlock()
+		if received.sig != nil {
+			return received.sig
+		}
+	}
+	return os.Interrupt
+}
+
+// a context that carries the values of its parent (e.g. the received signal)
+// but is never cancelled
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// return a context for the cleanup that follows the cancellation of ctx (e.g. hooks)
+// the cleanup is given the grace period to finish after ctx has been cancelled
+func cleanupContext(ctx context.Context, gracePeriod time.Duration) (context.Context, context.CancelFunc) {
+	if ctx.Err() == nil {
+		return context.WithCancel(ctx)
+	}
+	if gracePeriod <= 0 {
+		gracePeriod = DEFAULT_GRACE_PERIOD
+	}
+	return context.WithTimeout(detachedContext{ctx}, gracePeriod)
+}
+
+// was the context cancelled by an interrupt (e.g. Ctrl-C in the terminal)?
+// the terminal delivers the interrupt to all the processes of ork's process group
+func interruptedByTerminal(ctx context.Context) bool {
+	if received, ok := ctx.Value(signalKey{}).(*receivedSignal); ok {
+		received.mu.Lock()
+		defer received.mu.Unlock()
+		return received.sig == os.Interrupt
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package ork
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	RUNNER_SSH = "ssh" // the actions are executed on a remote host
+
+	DEFAULT_SSH_EXECUTABLE = "ssh"
+
+	// polls the remote shell's parent (sshd) in the background
+	SSH_WATCHDOG = "(while kill -0 $PPID 2>/dev/null; do sleep 1; done; kill -TERM 0) </dev/null >/dev/null 2>&1 & watchdog=$!; "
+)
+
+// the remote host on which the actions of a task are executed
+type SSH struct {
+	Host       string `yaml:"host"`
+	User       string `yaml:"user"`
+	Port       int    `yaml:"port"`
+	Key        string `yaml:"key"`         // the identity file
+	KnownHosts string `yaml:"known_hosts"` // the host keys will be verified against this file (if set)
+}
+
+func init() {
+	RegisterRunner(RUNNER_SSH, newSSHRunner)
+}
+
+// executes every action on a remote host using the ssh client
+// the action's statement is interpreted by the remote user's (POSIX) shell after
+// the environment of the run has been exported and the working directory changed
+type SSHRunner struct {
+	ssh SSH
+	env []string // the keys of the task's own environment
+}
+
+func newSSHRunner(task *Task) (Runner, error) {
+	if task.SSH == nil || task.SSH.Host == "" {
+		return nil, &OrkfileError{errors.New("ssh host has not been set")}
+	}
+	env := []string{}
+	for _, e := range task.Env {
+		env = append(env, e.Keys()...)
+	}
+	return &SSHRunner{ssh: *task.SSH, env: env}, nil
+}
+
+func (r *SSHRunner) Command(statement string, dir string) (*exec.Cmd, error) {
+	return exec.Command(DEFAULT_SSH_EXECUTABLE, append(r.Args(), r.Script(statement, dir))...), nil
+}
+
+// the arguments of the ssh client up to (and including) the destination
+func (r *SSHRunner) Args() []string {
+	// never prompt for passwords or passphrases (ork may not be attached to a terminal)
+	args := []string{"-o", "BatchMode=yes"}
+	if r.ssh.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(r.ssh.Port))
+	}
+	if r.ssh.Key != "" {
+		args = append(args, "-i", r.ssh.Key)
+	}
+	if r.ssh.KnownHosts != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+r.ssh.KnownHosts, "-o", "StrictHostKeyChecking=yes")
+	}
+	destination := r.ssh.Host
+	if r.ssh.User != "" {
+		destination = r.ssh.User + "@" + destination
+	}
+	return append(args, "--", destination)
+}
+
+// the command line that will be executed on the remote host
+// the exported variables are the ones that have been set by ork (e.g. the environment
+// of the task and its parents, the prompts and the registered outputs) except for the
+// ones that contain secrets (the command line is visible to all the users of both hosts)
+// the remote processes are not signalled when the session ends (no tty is allocated),
+// so a watchdog terminates the session's process group when sshd has gone away
+// (e.g. after the ssh client has been interrupted)
+func (r *SSHRunner) Script(statement string, dir string) string {
+	var script strings.Builder
+	script.WriteString(SSH_WATCHDOG)
+	for _, key := range changedEnvKeys(r.env) {
+		if value := os.Getenv(key); !containsSecret(value) {
+			fmt.Fprintf(&script, "export %s=%s; ", key, shellQuote(value))
+		}
+	}
+	if dir != "" {
+		fmt.Fprintf(&script, "cd %s && ", shellQuote(dir))
+	}
+	script.WriteString(statement)
+	// (on a separate line, in case the statement ends with a comment)
+	script.WriteString("\nstatus=$?; kill $watchdog 2>/dev/null; exit $status")
+	return script.String()
+}
+
+// quote the value so that it is interpreted literally by a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
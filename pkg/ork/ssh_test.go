@@ -0,0 +1,147 @@
+package ork
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// install a fake ssh client in PATH that records its arguments in $SSH_ARGS
+// and executes the remote command locally
+func stubSSH(t *testing.T) string {
+	dir := t.TempDir()
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > \"$SSH_ARGS\"\nfor last; do :; done\n" +
+		"[ -n \"$ORK_TEST_SSH_CLEAN_ENV\" ] && exec env -i PATH=\"$PATH\" sh -c \"$last\"\nexec sh -c \"$last\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DEFAULT_SSH_EXECUTABLE), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	args := filepath.Join(dir, "args")
+	t.Setenv("SSH_ARGS", args)
+	return args
+}
+
+// only the variables that are set after this point are forwarded by the runners
+func resetInitialEnv(t *testing.T) {
+	saved := initialEnv
+	initialEnv = environ()
+	t.Cleanup(func() { initialEnv = saved })
+}
+
+func Test_Task_Runs_Actions_Over_SSH(t *testing.T) {
+	args := stubSSH(t)
+	remote := t.TempDir()
+	resetInitialEnv(t)
+
+	yml := `
+tasks:
+  - name: deploy
+    working_dir: ` + remote + `
+    expand_env: false
+    env:
+      - GREETING: "it's $HOME"
+    ssh:
+      host: app.example.com
+      user: deployer
+      port: 2222
+      key: ~/.ssh/deploy
+      known_hosts: ./known_hosts
+    actions:
+      - sh -c 'echo "$GREETING from $(pwd)"; exit 4'
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	logger := NewMockLogger()
+	err := f.RunTask(context.Background(), "deploy", logger)
+
+	var actionErr *ActionError
+	require.True(t, errors.As(err, &actionErr))
+	assert.Equal(t, 4, actionErr.ExitCode)
+	assert.Equal(t, "it's "+os.Getenv("HOME")+" from "+remote+"\n", strings.Join(logger.Outputs(), ""))
+
+	recorded, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"-o", "BatchMode=yes",
+		"-p", "2222",
+		"-i", "~/.ssh/deploy",
+		"-o", "UserKnownHostsFile=./known_hosts", "-o", "StrictHostKeyChecking=yes",
+		"--", "deployer@app.example.com",
+		SSH_WATCHDOG + "export GREETING='it'\\''s " + os.Getenv("HOME") + "'; cd '" + remote + "' && sh -c 'echo \"$GREETING from $(pwd)\"; exit 4'",
+		"status=$?; kill $watchdog 2>/dev/null; exit $status",
+	}, strings.Split(strings.TrimSuffix(string(recorded), "\n"), "\n"))
+}
+
+func Test_SSH_Forwards_The_Environment_Of_The_Run(t *testing.T) {
+	stubSSH(t)
+	// the stub executes the remote command with a clean environment
+	t.Setenv("ORK_TEST_SSH_CLEAN_ENV", "1")
+	// the variables that were set before ork started are not forwarded
+	t.Setenv("ORK_TEST_SSH_SHELL_VAR", "local")
+	resetInitialEnv(t)
+
+	yml := `
+tasks:
+  - name: deploy
+    env:
+      - ORK_TEST_SSH_STAGE: production
+    tasks:
+      - name: app
+        expand_env: false
+        prompt:
+          - name: ORK_TEST_SSH_VERSION
+        ssh:
+          host: app.example.com
+        actions:
+          - run: echo registered
+            register: ORK_TEST_SSH_REGISTERED
+          - sh -c 'echo "$ORK_TEST_SSH_STAGE $ORK_TEST_SSH_VERSION $ORK_TEST_SSH_REGISTERED [$ORK_TEST_SSH_SHELL_VAR]"'
+`
+	for _, key := range []string{"ORK_TEST_SSH_STAGE", "ORK_TEST_SSH_VERSION", "ORK_TEST_SSH_REGISTERED"} {
+		defer os.Unsetenv(key)
+	}
+	f := New().WithStdin(strings.NewReader("")).WithParams(map[string]string{"ORK_TEST_SSH_VERSION": "1.2"})
+	require.NoError(t, f.Parse([]byte(yml)))
+	logger := NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "deploy.app", logger))
+	assert.Equal(t, []string{"production 1.2 registered []\n"}, logger.Outputs())
+}
+
+func Test_SSH_Does_Not_Put_Secrets_On_The_Command_Line(t *testing.T) {
+	resetInitialEnv(t)
+	t.Setenv("ORK_TEST_SSH_STAGE", "production")
+	t.Setenv("ORK_TEST_SSH_TOKEN", "ork-test-ssh-s3cr3t")
+	addSecret("ork-test-ssh-s3cr3t")
+	t.Cleanup(func() {
+		secretsMu.Lock()
+		defer secretsMu.Unlock()
+		delete(secrets, "ork-test-ssh-s3cr3t")
+	})
+
+	script := (&SSHRunner{ssh: SSH{Host: "app.example.com"}}).Script("deploy", "")
+	assert.Contains(t, script, "export ORK_TEST_SSH_STAGE='production'; ")
+	assert.NotContains(t, script, "ORK_TEST_SSH_TOKEN")
+	assert.NotContains(t, script, "ork-test-ssh-s3cr3t")
+}
+
+func Test_Task_With_Container_And_SSH_Is_Ambiguous(t *testing.T) {
+	yml := `
+tasks:
+  - name: deploy
+    container:
+      image: alpine
+    ssh:
+      host: app.example.com
+    actions:
+      - echo hello
+`
+	f := New()
+	require.NoError(t, f.Parse([]byte(yml)))
+	err := f.RunTask(context.Background(), "deploy", NewMockLogger())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the runner is ambiguous")
+}
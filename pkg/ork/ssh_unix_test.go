@@ -0,0 +1,52 @@
+//go:build !windows
+// +build !windows
+
+package ork
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SSH_Remote_Command_Dies_With_The_Session(t *testing.T) {
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("setsid is not available")
+	}
+	// the remote command runs in its own session (as it would under sshd)
+	dir := t.TempDir()
+	script := "#!/bin/sh\nfor last; do :; done\nsetsid sh -c \"$last\" &\nwait\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DEFAULT_SSH_EXECUTABLE), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	pidfile := filepath.Join(dir, "pid")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		cancel()
+	}()
+	runner := &SSHRunner{ssh: SSH{Host: "app.example.com"}}
+	action := NewAction("sh -c 'echo $$ > " + pidfile + "; sleep 30'").
+		WithEnvExpansion(false).
+		WithStdin(strings.NewReader("")).
+		WithContext(ctx).
+		WithRunner(runner)
+	require.Error(t, action.Execute())
+
+	contents, err := os.ReadFile(pidfile)
+	require.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(pid, 0) != nil || isZombie(pid)
+	}, 5*time.Second, 50*time.Millisecond)
+}
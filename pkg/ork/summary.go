@@ -0,0 +1,108 @@
+package ork
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// the outcome of a single task execution
+type TaskSummary struct {
+	Label    string        `json:"task"`
+	Status   string        `json:"status"`
+	Duration time.Duration `json:"-"`
+	Actions  int           `json:"actions"` // the number of executed actions
+}
+
+// the duration is encoded in seconds (as in the rest of the structured events)
+func (t *TaskSummary) MarshalJSON() ([]byte, error) {
+	type plain TaskSummary
+	return json.Marshal(struct {
+		*plain
+		Duration float64 `json:"duration"`
+	}{(*plain)(t), t.Duration.Seconds()})
+}
+
+// the tasks executed during a run, in the order they were executed
+type RunSummary struct {
+	mu       sync.Mutex
+	Tasks    []*TaskSummary
+	Start    time.Time
+	Duration time.Duration
+	running  map[string]*TaskSummary
+}
+
+func NewRunSummary() *RunSummary {
+	return &RunSummary{Start: time.Now(), running: map[string]*TaskSummary{}}
+}
+
+func (s *RunSummary) Notify(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch e := e.(type) {
+	case TaskStarted:
+		task := &TaskSummary{Label: e.Task}
+		s.Tasks = append(s.Tasks, task)
+		s.running[e.Task] = task
+	case TaskSkipped:
+		s.Tasks = append(s.Tasks, &TaskSummary{Label: e.Task, Status: STATUS_SKIPPED})
+	case ActionFinished:
+		if task, ok := s.running[e.Task]; ok {
+			task.Actions++
+		}
+	case TaskFinished:
+		if task, ok := s.running[e.Task]; ok {
+			task.Status = e.LogEvent().Status
+			task.Duration = e.Duration
+			delete(s.running, e.Task)
+		}
+	case RunFinished:
+		s.Duration = time.Since(s.Start)
+	}
+}
+
+func status(err error) string {
+	if err != nil {
+		return STATUS_FAILED
+	}
+	return STATUS_OK
+}
+
+// the summary as a structured event
+func (s *RunSummary) LogEvent() LogEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.Duration.Seconds()
+	return LogEvent{Time: time.Now(), Event: EVENT_RUN_SUMMARY, Duration: &d, Tasks: s.Tasks}
+}
+
+// return the summary as a table
+func (s *RunSummary) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf := bytes.NewBuffer([]byte{})
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tSTATUS\tDURATION\tACTIONS")
+	for _, task := range s.Tasks {
+		duration := "-"
+		if task.Status != STATUS_SKIPPED {
+			duration = formatDuration(task.Duration)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", task.Label, task.Status, duration, task.Actions)
+	}
+	fmt.Fprintf(w, "total\t\t%s\t\n", formatDuration(s.Duration))
+	w.Flush()
+	return buf.String()
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(100 * time.Millisecond).String()
+	}
+}
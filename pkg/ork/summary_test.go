@@ -0,0 +1,131 @@
+package ork
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RunSummary_Records_Executed_Tasks(t *testing.T) {
+	yml := `
+tasks:
+  - name: ci
+    depends_on:
+      - lint
+      - test
+    actions:
+      - echo ci
+  - name: lint
+    actions:
+      - echo lint
+      - sleep 0.1
+  - name: test
+    actions:
+      - bash -c "exit 1"
+`
+	f := New().WithKeepGoing(true).WithSummary(true)
+	require.NoError(t, f.Parse([]byte(yml)))
+	log := NewMockLogger()
+	require.Error(t, f.Run(context.Background(), []string{"ci"}, log))
+
+	outputs := log.Outputs()
+	require.Equal(t, 2, len(outputs))
+	lines := strings.Split(strings.TrimSpace(outputs[1]), "\n")
+	require.Equal(t, 5, len(lines), outputs[1])
+	assert.Regexp(t, regexp.MustCompile(`^TASK\s+STATUS\s+DURATION\s+ACTIONS$`), lines[0])
+	assert.Regexp(t, regexp.MustCompile(`^lint\s+ok\s+1\d\dms\s+2$`), lines[1])
+	assert.Regexp(t, regexp.MustCompile(`^test\s+failed\s+\d+ms\s+1$`), lines[2])
+	assert.Regexp(t, regexp.MustCompile(`^ci\s+skipped\s+-\s+0$`), lines[3])
+	assert.Regexp(t, regexp.MustCompile(`^total\s+\d+ms$`), lines[4])
+}
+
+func Test_RunSummary_Table(t *testing.T) {
+	s := NewRunSummary()
+	s.Notify(TaskStarted{Task: "build"})
+	s.Notify(ActionFinished{Task: "build"})
+	s.Notify(TaskFinished{Task: "build", Duration: 2500 * time.Millisecond})
+	s.Notify(TaskSkipped{Task: "deploy"})
+	s.Duration = 3 * time.Second
+
+	assert.Equal(t, `TASK    STATUS   DURATION  ACTIONS
+build   ok       2.5s      1
+deploy  skipped  -         0
+total            3s        
+`, s.String())
+}
+
+func Test_RunSummary_Statuses(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.tmpl"), []byte("{{ .Task }}"), 0644))
+	yml := fmt.Sprintf(`
+tasks:
+  - name: config
+    working_dir: %s
+    render:
+      - src: config.tmpl
+        dst: config
+  - name: deploy
+    depends_on:
+      - missing
+    actions:
+      - echo deploy
+`, dir)
+	f := New().WithKeepGoing(true).WithSummary(true)
+	require.NoError(t, f.Parse([]byte(yml)))
+
+	summarize := func() []string {
+		log := NewMockLogger()
+		require.Error(t, f.Run(context.Background(), []string{"config", "deploy"}, log))
+		outputs := log.Outputs()
+		return strings.Split(strings.TrimSpace(outputs[len(outputs)-1]), "\n")
+	}
+	// the task that fails before its actions (e.g. a missing dependency) is recorded as well
+	lines := summarize()
+	require.Equal(t, 4, len(lines))
+	assert.Regexp(t, regexp.MustCompile(`^config\s+ok\s+\S+\s+0$`), lines[1])
+	assert.Regexp(t, regexp.MustCompile(`^deploy\s+failed\s+\S+\s+0$`), lines[2])
+
+	// the rendered file is up to date
+	lines = summarize()
+	assert.Regexp(t, regexp.MustCompile(`^config\s+up-to-date\s+\S+\s+0$`), lines[1])
+}
+
+func Test_RunSummary_In_Structured_Log(t *testing.T) {
+	yml := `
+tasks:
+  - name: build
+    actions:
+      - echo build
+`
+	buf := bytes.NewBuffer([]byte{})
+	f := New().WithSummary(true)
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.NoError(t, f.Run(context.Background(), []string{"build"}, NewJSONLogger(buf)))
+
+	events := parseEvents(t, buf)
+	summary := events[len(events)-1]
+	assert.Equal(t, EVENT_RUN_SUMMARY, summary.Event)
+	assert.NotNil(t, summary.Duration)
+	assert.NotContains(t, buf.String(), "TASK")
+
+	var raw struct {
+		Tasks []map[string]interface{} `json:"tasks"`
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &raw))
+	require.Equal(t, 1, len(raw.Tasks))
+	assert.Equal(t, "build", raw.Tasks[0]["task"])
+	assert.Equal(t, STATUS_OK, raw.Tasks[0]["status"])
+	assert.Equal(t, float64(1), raw.Tasks[0]["actions"])
+	assert.Contains(t, raw.Tasks[0], "duration")
+}
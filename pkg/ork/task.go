@@ -0,0 +1,735 @@
+package ork
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type graph map[*Task]*Task
+
+// the state that is shared among all the tasks (parents and dependencies)
+// that take part in the execution of a single task
+type execution struct {
+	cdt      graph    // dependencies between tasks in the form: key: parent, value: child
+	services Services // the background actions that need to be stopped when the execution ends
+	stack    []string // the labels of the tasks that are currently running (innermost last)
+	done     outcomes
+	logs     logFiles
+	events   *EventBus
+}
+
+// the outcomes (errors) of the tasks that have already been executed within a run
+// a task that is requested again (e.g. as a dependency of another task) is not re-executed
+// (parent tasks are executed along with each of their children)
+type outcomes map[string]error
+
+// the label of the innermost running task (if any)
+func (ex *execution) current() string {
+	if len(ex.stack) == 0 {
+		return ""
+	}
+	return ex.stack[len(ex.stack)-1]
+}
+
+func (ex *execution) enter(label string) { ex.stack = append(ex.stack, label) }
+
+func (ex *execution) leave() { ex.stack = ex.stack[:len(ex.stack)-1] }
+
+type TaskSelector func(*LabeledTask) bool
+
+// the ways in which the standard error of a task's actions can be handled
+const (
+	STDERR_SEPARATE = "separate" // routed through the logger separately from stdout (default)
+	STDERR_MERGE    = "merge"    // merged into stdout
+	STDERR_SUPPRESS = "suppress" // discarded
+)
+
+var (
+	Actionable TaskSelector = func(t *LabeledTask) bool { return t.IsActionable() }
+	All        TaskSelector = func(_ *LabeledTask) bool { return true }
+)
+
+type LabeledTask struct {
+	label string // the task's fully qualified name (the one visible to the user)
+	*Task
+	stdin       io.Reader
+	keepGoing   bool
+	gracePeriod time.Duration
+	outputMode  string
+	logDir      string
+	params      map[string]string
+	yes         bool
+	dryRun      bool
+	events      *EventBus
+	done        outcomes
+	logs        logFiles
+}
+
+type Requirements struct {
+	Exists []string          `yaml:"exists"`
+	Equals map[string]string `yaml:"equals"`
+}
+
+type Task struct {
+	label          string
+	project        string        // the directory of the Orkfile (the current directory if not set)
+	Name           string        `yaml:"name"`
+	Default        string        `yaml:"default"` // used in the global task
+	Description    string        `yaml:"description"`
+	WorkingDir     string        `yaml:"working_dir"`
+	Env            []Env         `yaml:"env"`
+	ExpandEnv      *bool         `yaml:"expand_env"`
+	GreedyEnvSubst *bool         `yaml:"env_subst_greedy"`
+	Actions        []TaskAction  `yaml:"actions"`
+	DependsOn      []string      `yaml:"depends_on"`
+	Tasks          []*Task       `yaml:"tasks"`
+	OnSuccess      []string      `yaml:"on_success"`
+	OnFailure      []string      `yaml:"on_failure"`
+	DynamicTasks   []*Task       `yaml:"generate"`
+	GenerateFrom   *Generator    `yaml:"generate_from"`
+	Requirements   *Requirements `yaml:"require"`
+	IgnoreError    bool          `yaml:"ignore_error"`
+	Stderr         string        `yaml:"stderr"`
+	LogFile        string        `yaml:"log_file"`
+	Silent         bool          `yaml:"silent"` // do not echo the actions at the info level
+	Runner         string        `yaml:"runner"` // the runner of the task's actions (default: local)
+	Container      *Container    `yaml:"container"`
+	SSH            *SSH          `yaml:"ssh"`
+	Template       bool          `yaml:"template"` // render the actions and env values as go templates
+	Render         []Rendering   `yaml:"render"`   // template files that are rendered before the actions
+	Prompt         []Prompt      `yaml:"prompt"`
+	Confirm        string        `yaml:"confirm"` // a question that needs to be answered with yes
+}
+
+// an action can be declared either as a plain statement
+// or as a mapping with the full set of attributes
+type TaskAction struct {
+	Run          string     `yaml:"run"`
+	IgnoreError  bool       `yaml:"ignore_error"`
+	Background   bool       `yaml:"background"`
+	Ready        *Readiness `yaml:"ready"`
+	Register     string     `yaml:"register"`      // capture the action's output in this env variable
+	RegisterJSON string     `yaml:"register_json"` // capture the action's JSON output in env variables
+}
+
+func (a TaskAction) IsCaptured() bool {
+	return a.Register != "" || a.RegisterJSON != ""
+}
+
+func (a *TaskAction) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&a.Run)
+	}
+	type plain TaskAction
+	return node.Decode((*plain)(a))
+}
+
+func (lt *LabeledTask) WithStdin(stdin io.Reader) *LabeledTask {
+	lt.stdin = stdin
+	return lt
+}
+
+func (lt *LabeledTask) WithKeepGoing(keepGoing bool) *LabeledTask {
+	lt.keepGoing = keepGoing
+	return lt
+}
+
+func (lt *LabeledTask) WithGracePeriod(gracePeriod time.Duration) *LabeledTask {
+	lt.gracePeriod = gracePeriod
+	return lt
+}
+
+func (lt *LabeledTask) WithOutputMode(mode string) *LabeledTask {
+	lt.outputMode = mode
+	return lt
+}
+
+// record the output of the task's actions in a file (named after the label) in this directory
+func (lt *LabeledTask) WithLogDir(dir string) *LabeledTask {
+	lt.logDir = dir
+	return lt
+}
+
+// the parameters of the run that are available to templates
+func (lt *LabeledTask) WithParams(params map[string]string) *LabeledTask {
+	lt.params = params
+	return lt
+}
+
+// assume yes in the task's confirmations
+func (lt *LabeledTask) WithYes(yes bool) *LabeledTask {
+	lt.yes = yes
+	return lt
+}
+
+// describe the task's actions instead of executing them
+func (lt *LabeledTask) WithDryRun(dryRun bool) *LabeledTask {
+	lt.dryRun = dryRun
+	return lt
+}
+
+// publish the events of the execution to this bus
+// (by default, the events are only presented by the logger)
+func (lt *LabeledTask) WithEventBus(events *EventBus) *LabeledTask {
+	lt.events = events
+	return lt
+}
+
+// share the outcomes of the tasks across the executions of a run
+func (lt *LabeledTask) withOutcomes(done outcomes) *LabeledTask {
+	lt.done = done
+	return lt
+}
+
+// share the opened log files across the executions of a run
+func (lt *LabeledTask) withLogFiles(logs logFiles) *LabeledTask {
+	lt.logs = logs
+	return lt
+}
+
+// propagate the runtime settings of the current task to the other task
+func (lt *LabeledTask) propagate(other *LabeledTask) *LabeledTask {
+	return other.
+		WithStdin(lt.stdin).
+		WithKeepGoing(lt.keepGoing).
+		WithGracePeriod(lt.gracePeriod).
+		WithOutputMode(lt.outputMode).
+		WithLogDir(lt.logDir).
+		WithParams(lt.params).
+		WithYes(lt.yes).
+		WithDryRun(lt.dryRun)
+}
+
+// execute the task
+// any background actions will be stopped after the task has finished
+func (lt *LabeledTask) Execute(ctx context.Context, inventory Inventory, logger Logger) error {
+	ex := &execution{cdt: graph{}, events: lt.events, done: lt.done, logs: lt.logs}
+	if ex.events == nil {
+		ex.events = loggerBus(logger)
+	}
+	if ex.done == nil {
+		ex.done = outcomes{}
+	}
+	if ex.logs == nil {
+		ex.logs = logFiles{}
+	}
+	defer func() { ex.services.Stop(logger) }()
+	return lt.executeOnce(ctx, inventory, logger, ex)
+}
+
+// execute the task unless it has already been executed within the run
+// (in which case its previous outcome is returned)
+func (lt *LabeledTask) executeOnce(ctx context.Context, inventory Inventory, logger Logger, ex *execution) error {
+	if err, ok := ex.done[lt.label]; ok {
+		logger.Debugf("[%s] task has already been executed (%s)", lt.label, outcome(err))
+		return err
+	}
+	err := lt.execute(ctx, inventory, logger, ex)
+	ex.done[lt.label] = err
+	return err
+}
+
+// execute the task workflow
+// a failure of a task that ignores errors is logged and not returned
+func (lt *LabeledTask) execute(ctx context.Context, inventory Inventory, logger Logger, ex *execution) error {
+	err := lt.run(ctx, inventory, logger, ex)
+	if err != nil && lt.IgnoreError {
+		logger.Errorf("[%s] ignoring task failure: %v", lt.label, err)
+		return nil
+	}
+	return err
+}
+
+// run the task workflow
+// return the first encountered error (if any) or, if the task keeps going,
+// the errors of all the failed dependencies
+func (lt *LabeledTask) run(ctx context.Context, inventory Inventory, logger Logger, ex *execution) (err error) {
+	// the output of the task's actions and hooks
+	out := newTaskOutput(logger, ex.events, lt.label, lt.outputMode)
+	defer out.Flush()
+
+	// the task whose execution triggered this one (as a parent or a dependency)
+	caller := ex.current()
+	ex.enter(lt.label)
+	defer ex.leave()
+
+	// the time at which the task's own workflow started (after its parents and dependencies)
+	var start time.Time
+	// the task is skipped if one of its parents or dependencies has failed
+	skipped := false
+	// whether any of the task's template files has been rendered
+	rendered := false
+
+	// handle success/failure hooks
+	defer func() {
+		if start.IsZero() && !skipped && err != nil {
+			// the task failed before its own workflow started (e.g. a missing dependency)
+			start = time.Now()
+			ex.events.Publish(TaskStarted{Time: start, Task: lt.label, Parent: caller})
+		}
+		hook := "on_success"
+		actions := lt.OnSuccess
+		if err != nil {
+			// set the ORK_ERROR env variable
+			if os.Setenv("ORK_ERROR", err.Error()) != nil {
+				logger.Errorf("[%s] failed to set the ORK_ERROR environment variable", lt.label)
+			}
+			hook = "on_failure"
+			actions = lt.OnFailure
+		}
+		if len(actions) > 0 {
+			logger.Debugf("[%s] executing %d %s hook(s)", lt.label, len(actions), hook)
+		} else {
+			logger.Tracef("[%s] no %s hooks to execute", lt.label, hook)
+		}
+		// the hooks need to run (e.g. to clean up) even if the run has been interrupted
+		hookCtx, cancel := cleanupContext(ctx, lt.gracePeriod)
+		defer cancel()
+		for _, a := range actions {
+			logger.Debugf("[%s] %s: %s", lt.label, hook, a)
+			hookStart := time.Now()
+			ex.events.Publish(HookStarted{Time: hookStart, Task: lt.label, Hook: hook, Action: a})
+			herr := lt.executeAction(hookCtx, a, out)
+			out.EndAction()
+			ex.events.Publish(HookFinished{Time: time.Now(), Task: lt.label, Hook: hook, Action: a, Duration: time.Since(hookStart), Err: herr})
+			if herr != nil {
+				logger.Errorf("[%s] failed to execute hook: %v", lt.label, herr)
+			}
+		}
+		if !start.IsZero() {
+			logger.Debugf("[%s] task %s in %s", lt.label, outcome(err), formatDuration(time.Since(start)))
+			upToDate := len(lt.Render) > 0 && len(lt.Actions) == 0 && !rendered
+			ex.events.Publish(TaskFinished{Time: time.Now(), Task: lt.label, Duration: time.Since(start), Err: err, UpToDate: upToDate})
+		} else if skipped {
+			ex.events.Publish(TaskSkipped{Time: time.Now(), Task: lt.label, Parent: caller})
+		}
+	}()
+
+	// let's visit and execute any parent tasks first recursively
+	if parent := findParent(lt.label, inventory); parent != nil {
+		if err := lt.propagate(parent).execute(ctx, inventory, logger, ex); err != nil {
+			skipped = true
+			return err
+		}
+	}
+
+	// first, execute all dependencies
+	logger.Tracef("[%s] executing dependencies", lt.label)
+	var failures MultiError
+	for _, label := range lt.DependsOn {
+		// find the dependency -- does it exist?
+		child := inventory.Find(label)
+		if child == nil {
+			err = &TaskError{Label: lt.label, Err: &OrkfileError{fmt.Errorf("dependency %s does not exist", label)}}
+			return
+		}
+
+		// should we visit the dependency?
+		if dep := ex.cdt[lt.Task]; child.Task == dep {
+			err = &TaskError{Label: lt.label, Err: &OrkfileError{fmt.Errorf("cyclic dependency detected: %s->%s", lt.Name, dep.Name)}}
+			return
+		}
+
+		// ok, let's run it
+		ex.cdt[lt.Task] = child.Task
+		if err = lt.propagate(child).executeOnce(ctx, inventory, logger, ex); err != nil {
+			// keep executing the remaining dependencies (unless interrupted)
+			if !lt.keepGoing || ctx.Err() != nil {
+				skipped = true
+				return
+			}
+			failures.Add(err)
+		}
+	}
+	// the task's own actions can not be executed if a dependency has failed
+	if err = failures.ErrorOrNil(); err != nil {
+		skipped = true
+		return
+	}
+
+	start = time.Now()
+	ex.events.Publish(TaskStarted{Time: start, Task: lt.label, Parent: caller})
+
+	// record the output of the task in its log file (if any)
+	if path := lt.logFilePath(); path != "" {
+		file, err := ex.logs.open(path)
+		if err != nil {
+			return &TaskError{Label: lt.label, Err: fmt.Errorf("failed to open log file: %w", err)}
+		}
+		out.WithLogFile(file)
+	}
+
+	// ask for confirmation and for the values of the prompts (if any)
+	if err = lt.prompt(logger); err != nil {
+		err = &TaskError{Label: lt.label, Err: err}
+		return
+	}
+
+	// are the requirements satisfied?
+	if err := lt.CheckRequirements(); err != nil {
+		ex.events.Publish(RequirementFailed{Time: time.Now(), Task: lt.label, Err: err})
+		return &TaskError{Label: lt.label, Err: fmt.Errorf("failed requirement: %w", err)}
+	} else if lt.Requirements != nil {
+		logger.Debugf("[%s] requirements satisfied", lt.label)
+	}
+
+	// apply the environment
+	for _, e := range lt.Env {
+		logger.Debugf("[%s] applying environment: %s", lt.label, strings.Join(e.Keys(), " "))
+		if e, err = lt.renderEnv(e); err != nil {
+			err = &TaskError{Label: lt.label, Err: err}
+			return
+		}
+		if err = e.Apply(lt.IsEnvSubstGreedy()); err != nil {
+			err = &TaskError{Label: lt.label, Err: fmt.Errorf("failed to apply environment: %w", err)}
+			return
+		}
+	}
+
+	// render the task's template files (if any)
+	if rendered, err = lt.renderFiles(logger); err != nil {
+		err = &TaskError{Label: lt.label, Err: err}
+		return
+	}
+
+	// execute all the task's actions (if any)
+	logger.Tracef("[%s] executing actions", lt.label)
+	for i, action := range lt.Actions {
+		if lt.Silent {
+			logger.Debugf("[%s] %s", lt.label, action.Run)
+		} else {
+			logger.Infof("[%s] %s", lt.label, action.Run)
+		}
+		actionStart := time.Now()
+		ex.events.Publish(ActionStarted{Time: actionStart, Task: lt.label, Action: action.Run})
+		if lt.dryRun {
+			// neither services are started nor outputs registered
+			err = lt.executeAction(ctx, action.Run, out)
+		} else if action.Background {
+			err = lt.startService(ctx, i, action, out, ex)
+		} else if action.IsCaptured() {
+			err = lt.captureAction(ctx, action, out)
+		} else {
+			err = lt.executeAction(ctx, action.Run, out)
+		}
+		out.EndAction()
+		ex.events.Publish(ActionFinished{Time: time.Now(), Task: lt.label, Action: action.Run, Duration: time.Since(actionStart), Err: err})
+		logger.Debugf("[%s] action %s in %s", lt.label, outcome(err), formatDuration(time.Since(actionStart)))
+		if err != nil {
+			err = &TaskError{Label: lt.label, Err: err}
+			if !action.IgnoreError || ctx.Err() != nil {
+				return
+			}
+			logger.Errorf("ignoring action failure: %v", err)
+			err = nil
+		}
+	}
+
+	return
+}
+
+func (lt *LabeledTask) CheckRequirements() error {
+	if lt.Requirements == nil {
+		return nil
+	}
+	for _, req := range lt.Requirements.Exists {
+		if _, exists := os.LookupEnv(req); !exists {
+			return fmt.Errorf("variable %s is not defined ", req)
+		}
+	}
+	for key, expected := range lt.Requirements.Equals {
+		actual, exists := os.LookupEnv(key)
+		if !exists {
+			return fmt.Errorf("variable %s has an expected value but does not exist in the environment", key)
+		}
+		// expand any environment variables in expected value
+		expected = os.ExpandEnv(expected)
+		if actual != expected {
+			return fmt.Errorf("variable %s exists but does not match its expected value", key)
+		}
+	}
+	return nil
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "failed"
+	}
+	return "succeeded"
+}
+
+func (t *Task) IsEnvSubstGreedy() bool {
+	if t.GreedyEnvSubst == nil {
+		return false
+	}
+	return *t.GreedyEnvSubst
+}
+
+// return the task's dynamic tasks, i.e. the statically defined ones
+// followed by the ones produced by the task's generator (if any)
+// the generator is executed in the task's working directory
+func (t *Task) GeneratedTasks() ([]*Task, error) {
+	if t.GenerateFrom == nil {
+		return t.DynamicTasks, nil
+	}
+	tasks, err := t.GenerateFrom.Generate(t.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]*Task{}, t.DynamicTasks...), tasks...), nil
+}
+
+func (t *Task) validateStderr() error {
+	switch t.Stderr {
+	case "", STDERR_SEPARATE, STDERR_MERGE, STDERR_SUPPRESS:
+		return nil
+	default:
+		return fmt.Errorf("unknown stderr mode: %s (one of %s, %s, %s)", t.Stderr, STDERR_SEPARATE, STDERR_MERGE, STDERR_SUPPRESS)
+	}
+}
+
+// builtins operate on the local filesystem, so they can only be executed by the local runner
+// (the errors of the runner itself are reported when the task is executed)
+func (t *Task) validateBuiltins() error {
+	name, err := t.runnerName()
+	if err != nil || name == RUNNER_LOCAL {
+		return nil
+	}
+	statements := append(append([]string{}, t.OnSuccess...), t.OnFailure...)
+	for _, action := range t.Actions {
+		statements = append(statements, action.Run)
+	}
+	for _, statement := range statements {
+		if isBuiltin(statement) {
+			return fmt.Errorf("builtins can not be executed by the %s runner: %s", name, statement)
+		}
+	}
+	return nil
+}
+
+// return the destination of the standard error of the task's actions
+func (t *Task) stderr(logger Logger) io.Writer {
+	switch t.Stderr {
+	case STDERR_MERGE:
+		return logger
+	case STDERR_SUPPRESS:
+		return io.Discard
+	default:
+		return StderrWriter{logger}
+	}
+}
+
+func (t *Task) IsActionable() bool {
+	return len(t.Actions) > 0 || len(t.DependsOn) > 0 || len(t.Render) > 0
+}
+
+// find and return the first parent of the current task if any
+// return nil if no parent was found
+func findParent(current string, inventory Inventory) *LabeledTask {
+	tokens := strings.Split(current, DEFAULT_TASK_GROUP_SEP)
+	n := len(tokens) - 1
+	for i := n; i > 0; i-- {
+		label := strings.Join(tokens[:i], DEFAULT_TASK_GROUP_SEP)
+		if parent := inventory.Find(label); parent != nil {
+			return parent
+		}
+	}
+	return nil
+}
+
+func (lt *LabeledTask) newAction(ctx context.Context, action string, logger Logger) (*Action, error) {
+	runner, err := lt.runner()
+	if err != nil {
+		return nil, err
+	}
+	data := newTemplateData(lt.label, lt.params)
+	if lt.Template {
+		rendered, err := data.Render(action)
+		if err != nil {
+			return nil, &ActionError{Action: action, ExitCode: -1, Err: err}
+		}
+		if rendered != action {
+			logger.Debugf("[%s] rendered: %s", lt.label, redact(rendered))
+		}
+		action = rendered
+	}
+	ee := true
+	if lt.ExpandEnv != nil {
+		ee = *lt.ExpandEnv
+	}
+	if expanded := os.ExpandEnv(action); ee && expanded != action {
+		logger.Debugf("[%s] expanded: %s", lt.label, redact(expanded))
+	}
+	return NewAction(action).
+		WithStdout(logger).
+		WithStderr(lt.stderr(logger)).
+		WithWorkingDirectory(lt.WorkingDir).
+		WithEnvExpansion(ee).
+		WithStdin(lt.stdin).
+		WithContext(ctx).
+		WithGracePeriod(lt.gracePeriod).
+		WithRunner(runner).
+		WithTemplateData(data).
+		WithDryRun(lt.dryRun), nil
+}
+
+// ask for the task's confirmation and export the values of its prompts
+func (lt *LabeledTask) prompt(logger Logger) error {
+	if lt.Confirm == "" && len(lt.Prompt) == 0 {
+		return nil
+	}
+	p := newPrompter(lt.stdin, lt.params, lt.yes)
+	// nothing needs to be confirmed in dry-run mode
+	if lt.Confirm != "" && !lt.dryRun {
+		if err := p.Confirm(lt.Confirm); err != nil {
+			return err
+		}
+	}
+	for _, prompt := range lt.Prompt {
+		value, err := p.Ask(prompt)
+		if err != nil {
+			return err
+		}
+		if prompt.Type == PROMPT_PASSWORD {
+			addSecret(value)
+		}
+		logger.Debugf("[%s] setting %s from prompt", lt.label, prompt.Name)
+		if err := os.Setenv(prompt.Name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render the template files into their destinations (relative to the working directory)
+// destinations whose contents are up to date are not modified
+// return whether any of the destinations has been (or would be) modified
+func (lt *LabeledTask) renderFiles(logger Logger) (bool, error) {
+	data := newTemplateData(lt.label, lt.params)
+	rendered := false
+	for _, r := range lt.Render {
+		if r.Src == "" || r.Dst == "" {
+			return false, &OrkfileError{errors.New("both src and dst need to be set in render")}
+		}
+		src, dst := r.Src, r.Dst
+		if lt.WorkingDir != "" {
+			if !filepath.IsAbs(src) {
+				src = filepath.Join(lt.WorkingDir, src)
+			}
+			if !filepath.IsAbs(dst) {
+				dst = filepath.Join(lt.WorkingDir, dst)
+			}
+		}
+		render := data.RenderFile
+		if lt.dryRun {
+			render = data.wouldRenderFile
+		}
+		changed, err := render(os.ExpandEnv(src), os.ExpandEnv(dst))
+		if err != nil {
+			return false, fmt.Errorf("failed to render %s: %w", r.Src, err)
+		}
+		rendered = rendered || changed
+		if changed && lt.dryRun {
+			logger.Infof("[%s] would render %s -> %s", lt.label, r.Src, r.Dst)
+		} else if changed {
+			logger.Infof("[%s] rendered %s -> %s", lt.label, r.Src, r.Dst)
+		} else {
+			logger.Debugf("[%s] %s is up to date", lt.label, r.Dst)
+		}
+	}
+	return rendered, nil
+}
+
+// render the env values as templates (if requested)
+func (lt *LabeledTask) renderEnv(env Env) (Env, error) {
+	if !lt.Template {
+		return env, nil
+	}
+	data := newTemplateData(lt.label, lt.params)
+	rendered := Env{}
+	for key, value := range env {
+		v, err := data.Render(value)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key, err)
+		}
+		rendered[key] = v
+	}
+	return rendered, nil
+}
+
+func (lt *LabeledTask) executeAction(ctx context.Context, action string, logger Logger) error {
+	a, err := lt.newAction(ctx, action, logger)
+	if err != nil {
+		return err
+	}
+	if err := a.Execute(); err != nil {
+		return err
+	}
+	// should we proceed to the next action?
+	select {
+	case <-ctx.Done():
+		return ErrInterrupted
+	default:
+		return nil
+	}
+}
+
+// start the action in the background and wait until it is ready
+// the action will keep running until the end of the execution
+func (lt *LabeledTask) startService(ctx context.Context, index int, action TaskAction, logger Logger, ex *execution) error {
+	// the services are started at most once per execution
+	// (e.g. parent tasks are executed along with each of their children)
+	if ex.services.started(lt.label, index) {
+		logger.Debugf("[%s] service has already been started: %s", lt.label, action.Run)
+		return nil
+	}
+	// a background action can not read from ork's standard input
+	a, err := lt.newAction(ctx, action.Run, logger)
+	if err != nil {
+		return err
+	}
+	// the readiness command is executed like the task's actions (without its output)
+	probe := func(statement string) (*Action, error) {
+		p, err := lt.newAction(ctx, statement, logger)
+		if err != nil {
+			return nil, err
+		}
+		return p.WithStdout(io.Discard).WithStdin(bytes.NewReader(nil)), nil
+	}
+	svc, err := startService(ctx, lt.label, a.WithStdin(bytes.NewReader(nil)), action.Ready, probe, logger)
+	if err != nil {
+		return err
+	}
+	svc.index = index
+	ex.services.Add(svc)
+	return nil
+}
+
+// execute the action and register its output in the environment
+func (lt *LabeledTask) captureAction(ctx context.Context, action TaskAction, logger Logger) error {
+	buf := bytes.NewBuffer([]byte{})
+	a, err := lt.newAction(ctx, action.Run, logger)
+	if err != nil {
+		return err
+	}
+	if err := a.WithStdout(buf).Execute(); err != nil {
+		return err
+	}
+	logger.Tracef("[%s] registering output: %s", lt.label, buf.String())
+	if err := action.register(buf.String()); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ErrInterrupted
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package ork
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// the data that is available to the templates of a task, e.g. {{ .Task }}
+type TemplateData struct {
+	Task   string            // the label of the task
+	OS     string            // e.g. linux
+	Arch   string            // e.g. amd64
+	Params map[string]string // the parameters of the run (see Orkfile.WithParams)
+}
+
+func newTemplateData(label string, params map[string]string) TemplateData {
+	if params == nil {
+		params = map[string]string{}
+	}
+	return TemplateData{Task: label, OS: runtime.GOOS, Arch: runtime.GOARCH, Params: params}
+}
+
+// the functions that are available to templates
+func (d TemplateData) funcs() template.FuncMap {
+	return template.FuncMap{
+		"env":   os.Getenv,
+		"param": func(key string) string { return d.Params[key] },
+		// {{ env "STAGE" | default "dev" }}
+		"default": func(def string, value string) string {
+			if value == "" {
+				return def
+			}
+			return value
+		},
+		// {{ range env "SERVICES" | split "," }}
+		"split": func(sep string, s string) []string {
+			if s == "" {
+				return []string{}
+			}
+			return strings.Split(s, sep)
+		},
+		"join":       func(sep string, items []string) string { return strings.Join(items, sep) },
+		"sha256File": sha256File,
+		"now":        time.Now,
+	}
+}
+
+func (d TemplateData) parse(name string, text string) (*template.Template, error) {
+	return template.New(name).Funcs(d.funcs()).Option("missingkey=error").Parse(text)
+}
+
+// render the text as a go template
+func (d TemplateData) Render(text string) (string, error) {
+	tmpl, err := d.parse("", text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %v", err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, d); err != nil {
+		return "", fmt.Errorf("failed to render template: %v", err)
+	}
+	return out.String(), nil
+}
+
+// a template file that is rendered into its destination
+type Rendering struct {
+	Src string `yaml:"src"`
+	Dst string `yaml:"dst"`
+}
+
+// render the src template into the dst file (with the permissions of src)
+// dst is left untouched (and false is returned) if its contents are up to date
+func (d TemplateData) RenderFile(src string, dst string) (bool, error) {
+	out, mode, changed, err := d.renderFile(src, dst)
+	if err != nil || !changed {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(dst, out, mode)
+}
+
+// whether rendering the src template would modify the dst file
+func (d TemplateData) wouldRenderFile(src string, dst string) (bool, error) {
+	_, _, changed, err := d.renderFile(src, dst)
+	return changed, err
+}
+
+// the rendered contents of src (along with its permissions)
+// and whether they differ from the current contents of dst
+func (d TemplateData) renderFile(src string, dst string) ([]byte, os.FileMode, bool, error) {
+	contents, err := os.ReadFile(src)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	tmpl, err := d.parse(filepath.Base(src), string(contents))
+	if err != nil {
+		return nil, 0, false, err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, d); err != nil {
+		return nil, 0, false, err
+	}
+	if current, err := os.ReadFile(dst); err == nil && bytes.Equal(current, out.Bytes()) {
+		return out.Bytes(), info.Mode().Perm(), false, nil
+	}
+	return out.Bytes(), info.Mode().Perm(), true, nil
+}
+
+// the hex-encoded sha256 checksum of the file's contents
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,119 @@
+package ork
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apsdehal/go-logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TemplateData_Render(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+	t.Setenv("SERVICES", "api,web")
+
+	data := newTemplateData("deploy", map[string]string{"stage": "ci"})
+	kases := []struct {
+		template string
+		expected string
+	}{
+		{`{{ .Task }} on {{ .OS }}/{{ .Arch }}`, "deploy on " + runtime.GOOS + "/" + runtime.GOARCH},
+		{`{{ param "stage" }} {{ .Params.stage }}`, "ci ci"},
+		{`{{ param "region" | default "eu" }}`, "eu"},
+		{`{{ range env "SERVICES" | split "," }}[{{ . }}]{{ end }}`, "[api][web]"},
+		{`{{ env "SERVICES" | split "," | join " " }}`, "api web"},
+		{`{{ sha256File "` + path + `" }}`, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{`{{ if eq .OS "plan9" }}plan9{{ else }}other{{ end }}`, "other"},
+		{`{{ now.Year | printf "%d" | len }}`, "4"},
+	}
+	for _, kase := range kases {
+		rendered, err := data.Render(kase.template)
+		require.NoError(t, err, kase.template)
+		assert.Equal(t, kase.expected, rendered, kase.template)
+	}
+
+	_, err := data.Render(`{{ .Params.region }}`)
+	assert.ErrorContains(t, err, "failed to render template")
+	_, err = data.Render(`{{ if }}`)
+	assert.ErrorContains(t, err, "failed to parse template")
+}
+
+func Test_Task_Renders_Templates(t *testing.T) {
+	yml := `
+tasks:
+  - name: build
+    template: true
+    env:
+      - TARGETS: '{{ .OS }}-{{ .Arch }},plan9-386'
+    actions:
+      - echo {{ range env "TARGETS" | split "," }}[{{ . }}]{{ end }}
+  - name: plain
+    env:
+      - VALUE: '{{ .Task }}'
+    actions:
+      - echo '{{ .Task }}' $VALUE
+`
+	f := New().WithParams(map[string]string{"stage": "ci"})
+	require.NoError(t, f.Parse([]byte(yml)))
+
+	logger := NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "build", logger))
+	assert.Equal(t, "["+runtime.GOOS+"-"+runtime.GOARCH+"][plan9-386]\n", strings.Join(logger.Outputs(), ""))
+
+	// templates are only rendered when requested
+	logger = NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "plain", logger))
+	assert.Equal(t, "{{ .Task }} {{ .Task }}\n", strings.Join(logger.Outputs(), ""))
+}
+
+func Test_Task_Renders_Files(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nginx.conf.tmpl"), []byte(
+		`listen {{ env "PORT" }};{{ if eq (param "stage") "production" }} ssl on;{{ end }}`), 0600))
+	yml := `
+tasks:
+  - name: config
+    working_dir: ` + dir + `
+    env:
+      - PORT: 8080
+    render:
+      - src: nginx.conf.tmpl
+        dst: conf/nginx.conf
+`
+	f := New().WithParams(map[string]string{"stage": "production"})
+	require.NoError(t, f.Parse([]byte(yml)))
+	assert.Contains(t, f.Labels(Actionable), "config")
+
+	log := NewMockLogger()
+	require.NoError(t, f.RunTask(context.Background(), "config", log))
+	dst := filepath.Join(dir, "conf", "nginx.conf")
+	contents, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "listen 8080; ssl on;", string(contents))
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Contains(t, log.Logs(logger.InfoLevel), "[config] rendered nginx.conf.tmpl -> conf/nginx.conf")
+
+	// an up-to-date destination is not modified
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(dst, past, past))
+	require.NoError(t, f.RunTask(context.Background(), "config", NewMockLogger()))
+	info, err = os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, past, info.ModTime())
+
+	// but it is rendered again when the output changes
+	require.NoError(t, f.WithParams(nil).RunTask(context.Background(), "config", NewMockLogger()))
+	contents, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "listen 8080;", string(contents))
+}
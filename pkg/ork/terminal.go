@@ -0,0 +1,17 @@
+package ork
+
+import (
+	"io"
+	"os"
+)
+
+// is the file a terminal?
+// character devices that are not terminals (e.g. /dev/null) are not terminals
+func IsTerminal(f *os.File) bool {
+	return f != nil && isTerminalFile(f)
+}
+
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && IsTerminal(f)
+}
@@ -0,0 +1,5 @@
+package ork
+
+import "syscall"
+
+const ioctlReadTermios = syscall.TCGETS
@@ -0,0 +1,110 @@
+package ork
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// open a pseudo-terminal and return its (terminal) slave end
+func openPty(t *testing.T) *os.File {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+	var unlock int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		t.Skipf("failed to unlock the pseudo-terminal: %v", errno)
+	}
+	var n uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		t.Skipf("failed to find the pseudo-terminal: %v", errno)
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { slave.Close() })
+	return slave
+}
+
+// a context that has been cancelled by the signal
+func signalledContext(sig os.Signal) context.Context {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), signalKey{}, &receivedSignal{sig: sig}))
+	cancel()
+	return ctx
+}
+
+func Test_IsTerminal(t *testing.T) {
+	null, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer null.Close()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	assert.True(t, IsTerminal(openPty(t)))
+	// /dev/null is a character device but not a terminal
+	assert.False(t, IsTerminal(null))
+	assert.False(t, IsTerminal(r))
+	assert.False(t, IsTerminal(nil))
+}
+
+func Test_OrkLogger_Terminal(t *testing.T) {
+	null, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer null.Close()
+	log, err := NewLoggerTo(openPty(t), null)
+	require.NoError(t, err)
+	assert.True(t, isTerminalOutput(log, STREAM_STDOUT))
+	assert.False(t, isTerminalOutput(log, STREAM_STDERR))
+}
+
+func Test_Action_In_Orks_Process_Group_Is_Not_Interrupted_Again(t *testing.T) {
+	action := NewAction("sleep 30").
+		WithStdin(openPty(t)).
+		WithContext(signalledContext(os.Interrupt)).
+		WithGracePeriod(100 * time.Millisecond)
+
+	// the interrupt has been delivered by the terminal (so the action is killed instead)
+	var aerr *ActionError
+	require.ErrorAs(t, action.Execute(), &aerr)
+	assert.Equal(t, 128+int(syscall.SIGKILL), aerr.ExitCode)
+}
+
+func Test_Action_In_Orks_Process_Group_Receives_Signals_Sent_To_Ork(t *testing.T) {
+	pidfile := filepath.Join(t.TempDir(), "child.pid")
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), signalKey{}, &receivedSignal{sig: syscall.SIGTERM}))
+	action := NewAction("bash -c \"sleep 30 & echo $! > " + pidfile + "; wait\"").
+		WithEnvExpansion(false).
+		WithStdin(openPty(t)).
+		WithContext(ctx).
+		WithGracePeriod(100 * time.Millisecond)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+	}()
+	// the signal was sent to ork alone (so it is forwarded)
+	var aerr *ActionError
+	require.ErrorAs(t, action.Execute(), &aerr)
+	assert.Equal(t, 128+int(syscall.SIGTERM), aerr.ExitCode)
+
+	contents, err := os.ReadFile(pidfile)
+	require.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(pid, 0) != nil || isZombie(pid)
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package ork
+
+import "os"
+
+// the attributes of terminals can not be read on this platform
+func isTerminalFile(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
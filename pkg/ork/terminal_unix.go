@@ -0,0 +1,17 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package ork
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// only terminals support reading their attributes
+func isTerminalFile(f *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlReadTermios, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package ork
+
+import (
+	"os"
+	"syscall"
+)
+
+// only consoles have a console mode
+func isTerminalFile(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
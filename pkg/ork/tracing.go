@@ -0,0 +1,309 @@
+package ork
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the OTLP span status codes
+const (
+	SPAN_STATUS_UNSET = 0
+	SPAN_STATUS_OK    = 1
+	SPAN_STATUS_ERROR = 2
+
+	DEFAULT_OTLP_TRACES_PATH = "/v1/traces"
+)
+
+// a tracer that records a span per run, per task (with the tasks that were executed
+// as parents or dependencies of a task as its child spans) and per action
+// the spans are exported in the OTLP JSON format (to a file or an OTLP/HTTP endpoint)
+type Tracer struct {
+	mu      sync.Mutex
+	traceID string
+	root    *span
+	spans   []*span
+	tasks   map[string]*span // the running tasks
+	actions map[string]*span // the running action of each task
+}
+
+type span struct {
+	id         string
+	parent     *span
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes []otlpAttribute
+	status     int
+	message    string
+}
+
+func NewTracer() *Tracer {
+	t := &Tracer{traceID: randomID(16), tasks: map[string]*span{}, actions: map[string]*span{}}
+	t.root = t.newSpan("ork", nil, time.Now())
+	return t
+}
+
+func (t *Tracer) newSpan(name string, parent *span, start time.Time) *span {
+	s := &span{id: randomID(8), parent: parent, name: name, start: start}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+// the span of the task (created if the task has not started yet)
+func (t *Tracer) task(label string, parent string, start time.Time) *span {
+	if s, ok := t.tasks[label]; ok {
+		if parent != "" && s.parent == t.root {
+			// the span was created before the task's own parent was known
+			s.parent = t.task(parent, "", start)
+		}
+		return s
+	}
+	p := t.root
+	if parent != "" {
+		// the parent task has not started yet while its own parents
+		// and dependencies are running
+		p = t.task(parent, "", start)
+	}
+	s := t.newSpan(label, p, start)
+	s.attributes = append(s.attributes, stringAttribute("ork.task", label))
+	t.tasks[label] = s
+	return s
+}
+
+func (t *Tracer) Notify(event Event) {
+	if finished, ok := event.(RunFinished); ok {
+		t.Finish(finished.Labels, finished.Err)
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := event.LogEvent()
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	switch e.Event {
+	case EVENT_TASK_STARTED:
+		t.task(e.Task, e.Parent, e.Time)
+	case EVENT_TASK_SKIPPED:
+		s := t.task(e.Task, e.Parent, e.Time)
+		s.end = e.Time
+		s.attributes = append(s.attributes, stringAttribute("ork.status", STATUS_SKIPPED))
+		delete(t.tasks, e.Task)
+	case EVENT_TASK_FINISHED:
+		if s, ok := t.tasks[e.Task]; ok {
+			s.finish(e)
+			delete(t.tasks, e.Task)
+		}
+	case EVENT_ACTION_STARTED, EVENT_HOOK_STARTED:
+		if task, ok := t.tasks[e.Task]; ok {
+			name := e.Action
+			if e.Hook != "" {
+				name = fmt.Sprintf("%s: %s", e.Hook, e.Action)
+			}
+			s := t.newSpan(name, task, e.Time)
+			s.attributes = append(s.attributes,
+				stringAttribute("ork.task", e.Task),
+				stringAttribute("process.command_line", redact(e.Action)))
+			if e.Hook != "" {
+				s.attributes = append(s.attributes, stringAttribute("ork.hook", e.Hook))
+			}
+			t.actions[e.Task] = s
+		}
+	case EVENT_ACTION_FINISHED, EVENT_HOOK_FINISHED:
+		if s, ok := t.actions[e.Task]; ok {
+			s.finish(e)
+			delete(t.actions, e.Task)
+		}
+	}
+}
+
+func (s *span) finish(e LogEvent) {
+	s.end = e.Time
+	s.attributes = append(s.attributes, stringAttribute("ork.status", e.Status))
+	if e.Duration != nil {
+		s.attributes = append(s.attributes, doubleAttribute("ork.duration", *e.Duration))
+	}
+	if e.ExitCode != nil {
+		s.attributes = append(s.attributes, intAttribute("process.exit.code", *e.ExitCode))
+	}
+	s.setStatus(e.Error)
+}
+
+func (s *span) setStatus(err string) {
+	if err == "" {
+		s.status = SPAN_STATUS_OK
+		return
+	}
+	s.status = SPAN_STATUS_ERROR
+	s.message = redact(err)
+}
+
+// mark the end of the run with its outcome
+func (t *Tracer) Finish(labels []string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.root.end = time.Now()
+	t.root.attributes = append(t.root.attributes, stringAttribute("ork.tasks", strings.Join(labels, " ")))
+	if err != nil {
+		t.root.attributes = append(t.root.attributes, intAttribute("process.exit.code", ExitCode(err)))
+		t.root.setStatus(err.Error())
+	} else {
+		t.root.setStatus("")
+	}
+	// any spans that are still open (e.g. after an interrupt) end with the run
+	for _, s := range t.spans {
+		if s.end.IsZero() {
+			s.end = t.root.end
+		}
+	}
+}
+
+// return the trace in the OTLP JSON format
+func (t *Tracer) Marshal() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := []otlpSpan{}
+	for _, s := range t.spans {
+		// a task's span includes the execution of its parents and dependencies
+		start := s.start
+		for _, c := range t.spans {
+			if c.parent == s && c.start.Before(start) {
+				start = c.start
+			}
+		}
+		parent := ""
+		if s.parent != nil {
+			parent = s.parent.id
+		}
+		spans = append(spans, otlpSpan{
+			TraceID:           t.traceID,
+			SpanID:            s.id,
+			ParentSpanID:      parent,
+			Name:              s.name,
+			Kind:              1, // internal
+			StartTimeUnixNano: strconv.FormatInt(start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        s.attributes,
+			Status:            otlpStatus{Code: s.status, Message: s.message},
+		})
+	}
+	return json.Marshal(otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{stringAttribute("service.name", "ork")}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "ork", Version: Version},
+			Spans: spans,
+		}},
+	}}})
+}
+
+// export the trace to the destination which is either a file
+// or the URL of an OTLP/HTTP endpoint (e.g. http://localhost:4318)
+func (t *Tracer) Export(destination string) error {
+	b, err := t.Marshal()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(destination, "http://") && !strings.HasPrefix(destination, "https://") {
+		return os.WriteFile(destination, append(b, '\n'), 0644)
+	}
+	u, err := url.Parse(destination)
+	if err != nil {
+		return err
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = DEFAULT_OTLP_TRACES_PATH
+	}
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(u.String(), "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s responded with %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// the OTLP JSON encoding of traces
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 values are encoded as strings
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func stringAttribute(key string, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int) otlpAttribute {
+	v := strconv.Itoa(value)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &v}}
+}
+
+func doubleAttribute(key string, value float64) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{DoubleValue: &value}}
+}
@@ -0,0 +1,128 @@
+package ork
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseSpans(t *testing.T, contents []byte) map[string]otlpSpan {
+	traces := otlpTraces{}
+	require.NoError(t, json.Unmarshal(contents, &traces))
+	require.Equal(t, 1, len(traces.ResourceSpans))
+	require.Equal(t, 1, len(traces.ResourceSpans[0].ScopeSpans))
+	spans := map[string]otlpSpan{}
+	for _, s := range traces.ResourceSpans[0].ScopeSpans[0].Spans {
+		spans[s.Name] = s
+	}
+	return spans
+}
+
+func attribute(s otlpSpan, key string) *otlpValue {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return &a.Value
+		}
+	}
+	return nil
+}
+
+func Test_Tracer_Exports_Spans_To_File(t *testing.T) {
+	yml := `
+tasks:
+  - name: ci
+    depends_on:
+      - lint
+      - test
+    actions:
+      - echo ci
+  - name: lint
+    actions:
+      - echo lint
+  - name: test
+    actions:
+      - bash -c "exit 3"
+    on_failure:
+      - echo failed
+`
+	path := filepath.Join(t.TempDir(), "trace.json")
+	f := New().WithTrace(path)
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.Error(t, f.Run(context.Background(), []string{"ci"}, NewMockLogger()))
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	spans := parseSpans(t, contents)
+	require.Equal(t, 7, len(spans))
+
+	root := spans["ork"]
+	assert.Empty(t, root.ParentSpanID)
+	assert.Equal(t, SPAN_STATUS_ERROR, root.Status.Code)
+	assert.Equal(t, "3", *attribute(root, "process.exit.code").IntValue)
+
+	// the dependencies are child spans of the task
+	ci := spans["ci"]
+	assert.Equal(t, root.SpanID, ci.ParentSpanID)
+	assert.Equal(t, "skipped", *attribute(ci, "ork.status").StringValue)
+	assert.Equal(t, ci.SpanID, spans["lint"].ParentSpanID)
+	assert.Equal(t, ci.SpanID, spans["test"].ParentSpanID)
+	assert.LessOrEqual(t, ci.StartTimeUnixNano, spans["lint"].StartTimeUnixNano)
+	for _, s := range spans {
+		assert.Equal(t, root.TraceID, s.TraceID)
+	}
+
+	// the actions are child spans of their task
+	action := spans[`bash -c "exit 3"`]
+	assert.Equal(t, spans["test"].SpanID, action.ParentSpanID)
+	assert.Equal(t, SPAN_STATUS_ERROR, action.Status.Code)
+	assert.Equal(t, "test", *attribute(action, "ork.task").StringValue)
+	assert.Equal(t, `bash -c "exit 3"`, *attribute(action, "process.command_line").StringValue)
+	assert.Equal(t, "3", *attribute(action, "process.exit.code").IntValue)
+	assert.NotNil(t, attribute(action, "ork.duration").DoubleValue)
+	assert.Equal(t, spans["test"].SpanID, spans["on_failure: echo failed"].ParentSpanID)
+	assert.Equal(t, SPAN_STATUS_OK, spans["echo lint"].Status.Code)
+}
+
+func Test_Tracer_Exports_Spans_To_OTLP_Endpoint(t *testing.T) {
+	var contents []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, DEFAULT_OTLP_TRACES_PATH, r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		contents, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	yml := `
+tasks:
+  - name: foo
+    actions:
+      - echo foo
+`
+	f := New().WithTrace(server.URL)
+	require.NoError(t, f.Parse([]byte(yml)))
+	require.NoError(t, f.Run(context.Background(), []string{"foo"}, NewMockLogger()))
+
+	spans := parseSpans(t, contents)
+	require.Equal(t, 3, len(spans))
+	assert.Equal(t, spans["ork"].SpanID, spans["foo"].ParentSpanID)
+	assert.Equal(t, spans["foo"].SpanID, spans["echo foo"].ParentSpanID)
+}
+
+func Test_Tracer_Reports_Endpoint_Failures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tracer := NewTracer()
+	tracer.Finish([]string{"foo"}, nil)
+	assert.ErrorContains(t, tracer.Export(server.URL), "503 Service Unavailable: unavailable")
+}